@@ -7,6 +7,7 @@ import (
 
 	"github.com/rodrwan/prettycat/internal/app"
 	"github.com/rodrwan/prettycat/internal/exitcode"
+	"github.com/rodrwan/prettycat/internal/render"
 )
 
 const version = "0.1.0"
@@ -15,10 +16,37 @@ func main() {
 	var (
 		showVersion bool
 		noColor     bool
+		width       int
+		wrap        string
+		number      bool
+		nonBlank    bool
+		language    string
+		fileName    string
+		listLangs   bool
+		sideBySide  bool
+		binary      string
+		recursive   bool
+		follow      bool
 	)
 
 	flag.BoolVar(&showVersion, "version", false, "print version")
 	flag.BoolVar(&noColor, "no-color", false, "disable ANSI colors")
+	flag.BoolVar(&number, "n", false, "number all lines of code and plain text")
+	flag.BoolVar(&number, "number", false, "number all lines of code and plain text")
+	flag.BoolVar(&nonBlank, "b", false, "number non-blank lines only")
+	flag.BoolVar(&nonBlank, "number-nonblank", false, "number non-blank lines only")
+	flag.StringVar(&language, "l", "", "force the renderer `language` (see --list-languages)")
+	flag.StringVar(&language, "language", "", "force the renderer `language` (see --list-languages)")
+	flag.StringVar(&fileName, "file-name", "", "`name` used to detect the language of stdin")
+	flag.BoolVar(&listLangs, "list-languages", false, "list supported languages and exit")
+	flag.BoolVar(&recursive, "r", false, "render every file inside directory arguments")
+	flag.BoolVar(&recursive, "recursive", false, "render every file inside directory arguments")
+	flag.BoolVar(&follow, "f", false, "keep reading data appended to the file, like tail -f")
+	flag.BoolVar(&follow, "follow", false, "keep reading data appended to the file, like tail -f")
+	flag.BoolVar(&sideBySide, "side-by-side", false, "show diffs in two columns sized to the terminal")
+	flag.StringVar(&binary, "binary", string(render.BinaryHex), "binary file `mode`: hex (dump), raw (print bytes as is), skip")
+	flag.IntVar(&width, "width", 0, "wrap output to `columns` (default: terminal width)")
+	flag.StringVar(&wrap, "wrap", string(render.WrapAuto), "wrap `mode`: auto (prose only), never, always (code too)")
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file ...]\n", os.Args[0])
 		fmt.Fprintln(flag.CommandLine.Output(), "Render beautiful terminal output for text, markdown and code files.")
@@ -31,23 +59,72 @@ func main() {
 		os.Exit(exitcode.Usage)
 	}
 
+	switch render.WrapMode(wrap) {
+	case render.WrapAuto, render.WrapNever, render.WrapAlways:
+	default:
+		fmt.Fprintf(os.Stderr, "prettycat: invalid --wrap value %q (want auto, never or always)\n", wrap)
+		os.Exit(exitcode.Usage)
+	}
+	switch render.BinaryMode(binary) {
+	case render.BinaryHex, render.BinaryRaw, render.BinarySkip:
+	default:
+		fmt.Fprintf(os.Stderr, "prettycat: invalid --binary value %q (want hex, raw or skip)\n", binary)
+		os.Exit(exitcode.Usage)
+	}
+	if width < 0 {
+		fmt.Fprintf(os.Stderr, "prettycat: invalid --width value %d\n", width)
+		os.Exit(exitcode.Usage)
+	}
+
+	if language != "" {
+		if _, ok := render.LookupLanguage(language); !ok {
+			fmt.Fprintf(os.Stderr, "prettycat: unknown language %q (see --list-languages)\n", language)
+			os.Exit(exitcode.Usage)
+		}
+	}
+
 	if showVersion {
 		fmt.Println(version)
 		os.Exit(exitcode.OK)
 	}
 
+	if listLangs {
+		if err := app.ListLanguages(os.Stdout); err != nil {
+			os.Exit(exitcode.Error)
+		}
+		os.Exit(exitcode.OK)
+	}
+
+	numberMode := render.NumberNone
+	switch {
+	case nonBlank:
+		numberMode = render.NumberNonBlank
+	case number:
+		numberMode = render.NumberAll
+	}
+
 	code := app.Run(app.Config{
-		Args:      flag.Args(),
-		Version:   version,
-		NoColor:   noColor,
-		Stdin:     os.Stdin,
-		Stdout:    os.Stdout,
-		Stderr:    os.Stderr,
-		IsTTYIn:   app.IsTTYFile,
-		IsTTYOut:  app.IsTTYFile,
-		OpenFile:  os.Open,
-		ReadAll:   app.ReadAll,
-		PagerOpen: app.RunPager,
+		Args:       flag.Args(),
+		Version:    version,
+		NoColor:    noColor,
+		Width:      width,
+		Wrap:       render.WrapMode(wrap),
+		Number:     numberMode,
+		Language:   language,
+		FileName:   fileName,
+		SideBySide: sideBySide,
+		Binary:     render.BinaryMode(binary),
+		Recursive:  recursive,
+		Follow:     follow,
+		Stdin:      os.Stdin,
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+		IsTTYIn:    app.IsTTYFile,
+		IsTTYOut:   app.IsTTYFile,
+		OpenFile:   os.Open,
+		ReadAll:    app.ReadAll,
+		PagerOpen:  app.RunPager,
+		TermWidth:  app.TerminalWidth,
 	})
 	os.Exit(code)
 }
@@ -2,6 +2,10 @@ module github.com/rodrwan/prettycat
 
 go 1.24.0
 
-require golang.org/x/term v0.39.0
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/ulikunitz/xz v0.5.15
+	golang.org/x/term v0.39.0
+)
 
 require golang.org/x/sys v0.40.0 // indirect
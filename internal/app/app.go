@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/rodrwan/prettycat/internal/exitcode"
 	"github.com/rodrwan/prettycat/internal/input"
@@ -14,17 +16,27 @@ import (
 )
 
 type Config struct {
-	Args      []string
-	Version   string
-	NoColor   bool
-	Stdin     *os.File
-	Stdout    *os.File
-	Stderr    io.Writer
-	IsTTYIn   func(*os.File) bool
-	IsTTYOut  func(*os.File) bool
-	OpenFile  input.FileOpener
-	ReadAll   input.ReadAllFn
-	PagerOpen func([]render.Doc, bool, io.Writer) error
+	Args       []string
+	Version    string
+	NoColor    bool
+	Width      int
+	Wrap       render.WrapMode
+	Number     render.NumberMode
+	Language   string
+	FileName   string
+	SideBySide bool
+	Recursive  bool
+	Follow     bool
+	Binary     render.BinaryMode
+	Stdin      *os.File
+	Stdout     *os.File
+	Stderr     io.Writer
+	IsTTYIn    func(*os.File) bool
+	IsTTYOut   func(*os.File) bool
+	OpenFile   input.FileOpener
+	ReadAll    input.ReadAllFn
+	PagerOpen  func([]render.Doc, pager.Options, io.Writer) error
+	TermWidth  func(*os.File) int
 }
 
 func Run(cfg Config) int {
@@ -33,56 +45,107 @@ func Run(cfg Config) int {
 		return exitcode.Usage
 	}
 
-	loaded, stdinHadData, err := input.Load(cfg.Args, cfg.Stdin, cfg.IsTTYIn, cfg.OpenFile, cfg.ReadAll)
+	if cfg.Follow && len(cfg.Args) > 1 {
+		fmt.Fprintln(cfg.Stderr, "prettycat: --follow takes a single file")
+		return exitcode.Usage
+	}
+
+	loadOpts := input.LoadOptions{Recursive: cfg.Recursive, Follow: cfg.Follow}
+	loaded, stdinHadData, err := input.Load(cfg.Args, loadOpts, cfg.Stdin, cfg.IsTTYIn, cfg.OpenFile, cfg.ReadAll)
 	if err != nil {
+		for _, e := range loaded.Errors {
+			fmt.Fprintf(cfg.Stderr, "prettycat: %v\n", e)
+		}
 		fmt.Fprintf(cfg.Stderr, "prettycat: %v\n", err)
 		return exitcode.Error
 	}
 
+	if cfg.Follow && (len(loaded.Sources) > 1 || loaded.Sources[0].IsDir) {
+		for _, src := range loaded.Sources {
+			if src.Rest != nil {
+				src.Rest.Close()
+			}
+		}
+		fmt.Fprintln(cfg.Stderr, "prettycat: --follow takes a single file")
+		return exitcode.Usage
+	}
+
 	if stdinHadData && len(cfg.Args) > 0 {
 		fmt.Fprintln(cfg.Stderr, "prettycat: stdin data ignored because file arguments were provided")
 	}
 
 	color := useColor(cfg.NoColor, cfg.Stdout)
+	width := cfg.Width
+	if width == 0 && cfg.TermWidth != nil && cfg.IsTTYOut(cfg.Stdout) {
+		width = cfg.TermWidth(cfg.Stdout)
+	}
+	opts := render.Options{Color: color, Width: width, Wrap: cfg.Wrap, Number: cfg.Number, Language: cfg.Language, SideBySide: cfg.SideBySide, Binary: cfg.Binary}
+	tty := cfg.IsTTYOut(cfg.Stdout)
 	docs := make([]render.Doc, 0, len(loaded.Sources))
 	hadErr := len(loaded.Errors) > 0
+	shown, skipped := 0, 0
 
 	for _, e := range loaded.Errors {
 		fmt.Fprintf(cfg.Stderr, "prettycat: %v\n", e)
 	}
 
 	for i, src := range loaded.Sources {
-		doc, err := render.Render(src, render.Options{Color: color, Width: 100})
+		if src.IsStdin && cfg.FileName != "" {
+			src.Name = cfg.FileName
+		}
+		if cfg.Binary == render.BinarySkip && input.IsBinary(src.Data) {
+			fmt.Fprintf(cfg.Stderr, "prettycat: %s: binary file skipped\n", src.Name)
+			if src.Rest != nil {
+				src.Rest.Close()
+			}
+			skipped++
+			continue
+		}
+		doc, err := render.Render(src, opts)
 		if err != nil {
 			hadErr = true
 			fmt.Fprintf(cfg.Stderr, "prettycat: %v\n", err)
 			continue
 		}
+		for _, w := range doc.Warnings {
+			fmt.Fprintf(cfg.Stderr, "prettycat: %s\n", w)
+		}
 		if len(loaded.Sources) > 1 {
-			doc.Body = style.Header(src.Name, color) + doc.Body
+			doc.Prepend(style.Header(src.Name, color))
 			if i < len(loaded.Sources)-1 {
-				doc.Body += style.Separator(color)
+				doc.Append(style.Separator(color))
 			}
 		}
-		docs = append(docs, doc)
+		shown++
+		if tty {
+			docs = append(docs, doc)
+			continue
+		}
+		readErr, err := writeDoc(cfg.Stdout, doc)
+		if err != nil {
+			fmt.Fprintf(cfg.Stderr, "prettycat: write output: %v\n", err)
+			return exitcode.Error
+		}
+		if readErr != nil {
+			hadErr = true
+			fmt.Fprintf(cfg.Stderr, "prettycat: %s: %v\n", src.Name, readErr)
+		}
 	}
 
-	if len(docs) == 0 {
+	if shown == 0 {
+		if skipped > 0 && !hadErr {
+			return exitcode.OK
+		}
 		return exitcode.Error
 	}
 
-	if cfg.IsTTYOut(cfg.Stdout) {
-		if err := cfg.PagerOpen(docs, color, cfg.Stdout); err != nil {
+	if tty {
+		// Reflow on resize unless --width fixed the width.
+		popts := pager.Options{Color: color, Reflow: cfg.Width == 0}
+		if err := cfg.PagerOpen(docs, popts, cfg.Stdout); err != nil {
 			fmt.Fprintf(cfg.Stderr, "prettycat: pager error: %v\n", err)
 			hadErr = true
 		}
-	} else {
-		for _, doc := range docs {
-			if _, err := io.WriteString(cfg.Stdout, normalize(doc.Body)); err != nil {
-				fmt.Fprintf(cfg.Stderr, "prettycat: write output: %v\n", err)
-				return exitcode.Error
-			}
-		}
 	}
 
 	if hadErr {
@@ -91,6 +154,35 @@ func Run(cfg Config) int {
 	return exitcode.OK
 }
 
+// writeDoc writes doc to w, rendering the blocks of a streamed document as
+// they are read. Errors reading the source are returned apart from errors
+// writing w.
+func writeDoc(w io.Writer, doc render.Doc) (readErr, err error) {
+	if doc.Stream == nil {
+		_, err = io.WriteString(w, normalize(doc.Body))
+		return nil, err
+	}
+	defer doc.Stream.Close()
+	if _, err = io.WriteString(w, doc.Body); err != nil {
+		return nil, err
+	}
+	for {
+		block, err := doc.Stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if _, werr := io.WriteString(w, block.Body); werr != nil {
+			return nil, werr
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+	}
+	_, err = io.WriteString(w, doc.Footer)
+	return readErr, err
+}
+
 func useColor(noColor bool, stdout *os.File) bool {
 	if noColor {
 		return false
@@ -119,10 +211,39 @@ func IsTTYFile(f *os.File) bool {
 	return (fi.Mode() & os.ModeCharDevice) != 0
 }
 
+// ListLanguages writes the table printed by --list-languages.
+func ListLanguages(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LANGUAGE\tNAME\tALIASES\tFILES")
+	for _, info := range render.SupportedLanguages() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.ID, info.Name, listOrDash(info.Aliases), listOrDash(info.Files))
+	}
+	return tw.Flush()
+}
+
+func listOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
+
+// TerminalWidth returns the column count of f, falling back to $COLUMNS and
+// then to 80 when f is not a terminal.
+func TerminalWidth(f *os.File) int {
+	if w, ok := pager.TerminalWidth(f); ok {
+		return w
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS"))); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
 func ReadAll(r io.Reader) ([]byte, error) {
 	return io.ReadAll(r)
 }
 
-func RunPager(docs []render.Doc, color bool, stdout io.Writer) error {
-	return pager.Run(docs, color, stdout)
+func RunPager(docs []render.Doc, opts pager.Options, stdout io.Writer) error {
+	return pager.Run(docs, opts, stdout)
 }
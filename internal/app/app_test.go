@@ -2,6 +2,7 @@ package app
 
 import (
 	"bytes"
+	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/rodrwan/prettycat/internal/exitcode"
+	"github.com/rodrwan/prettycat/internal/pager"
 	"github.com/rodrwan/prettycat/internal/render"
 )
 
@@ -37,7 +39,7 @@ func TestRunContinuesOnMissingFileAndReturnsError(t *testing.T) {
 		IsTTYOut: func(*os.File) bool { return false },
 		OpenFile: os.Open,
 		ReadAll:  io.ReadAll,
-		PagerOpen: func([]render.Doc, bool, io.Writer) error {
+		PagerOpen: func([]render.Doc, pager.Options, io.Writer) error {
 			t.Fatalf("pager should not be called")
 			return nil
 		},
@@ -75,9 +77,178 @@ func TestRunNoInputUsage(t *testing.T) {
 		IsTTYOut:  func(*os.File) bool { return false },
 		OpenFile:  os.Open,
 		ReadAll:   io.ReadAll,
-		PagerOpen: func([]render.Doc, bool, io.Writer) error { return nil },
+		PagerOpen: func([]render.Doc, pager.Options, io.Writer) error { return nil },
 	}
 	if got := Run(cfg); got != exitcode.Usage {
 		t.Fatalf("Run() = %d, want %d", got, exitcode.Usage)
 	}
 }
+
+func TestRunUsesTerminalWidthForPager(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "notes.md")
+	if err := os.WriteFile(file, []byte("alpha beta gamma delta epsilon zeta eta theta\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var body string
+	cfg := Config{
+		Args:      []string{file},
+		NoColor:   true,
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    io.Discard,
+		IsTTYIn:   func(*os.File) bool { return true },
+		IsTTYOut:  func(*os.File) bool { return true },
+		OpenFile:  os.Open,
+		ReadAll:   io.ReadAll,
+		TermWidth: func(*os.File) int { return 24 },
+		PagerOpen: func(docs []render.Doc, _ pager.Options, _ io.Writer) error {
+			body = docs[0].Body
+			return nil
+		},
+	}
+	if got := Run(cfg); got != exitcode.OK {
+		t.Fatalf("Run() = %d, want %d", got, exitcode.OK)
+	}
+	want := "alpha beta gamma delta\nepsilon zeta eta theta\n"
+	if body != want {
+		t.Fatalf("pager body = %q, want %q", body, want)
+	}
+}
+
+func TestRunFileNameHintAppliesToStdin(t *testing.T) {
+	tmp := t.TempDir()
+	stdinPath := filepath.Join(tmp, "stdin")
+	if err := os.WriteFile(stdinPath, []byte("x := 1\n"), 0o644); err != nil {
+		t.Fatalf("write stdin: %v", err)
+	}
+	stdin, err := os.Open(stdinPath)
+	if err != nil {
+		t.Fatalf("open stdin: %v", err)
+	}
+	defer stdin.Close()
+
+	var doc render.Doc
+	cfg := Config{
+		NoColor:   true,
+		FileName:  "main.go",
+		Stdin:     stdin,
+		Stdout:    os.Stdout,
+		Stderr:    io.Discard,
+		IsTTYIn:   func(*os.File) bool { return false },
+		IsTTYOut:  func(*os.File) bool { return true },
+		OpenFile:  os.Open,
+		ReadAll:   io.ReadAll,
+		PagerOpen: func(docs []render.Doc, _ pager.Options, _ io.Writer) error { doc = docs[0]; return nil },
+	}
+	if got := Run(cfg); got != exitcode.OK {
+		t.Fatalf("Run() = %d, want %d", got, exitcode.OK)
+	}
+	if doc.Language != "go" {
+		t.Fatalf("stdin language = %q, want go", doc.Language)
+	}
+}
+
+func TestListLanguages(t *testing.T) {
+	var b bytes.Buffer
+	if err := ListLanguages(&b); err != nil {
+		t.Fatalf("ListLanguages: %v", err)
+	}
+	for _, want := range []string{"markdown", "golang", "*.rs", "dockerfile", "plain"} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("listing is missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestRunSkipsBinaryFiles(t *testing.T) {
+	tmp := t.TempDir()
+	bin := filepath.Join(tmp, "a.out")
+	if err := os.WriteFile(bin, []byte("\x7fELF\x00\x00"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	cfg := Config{
+		Args:      []string{bin},
+		NoColor:   true,
+		Binary:    render.BinarySkip,
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    &stderr,
+		IsTTYIn:   func(*os.File) bool { return true },
+		IsTTYOut:  func(*os.File) bool { return true },
+		OpenFile:  os.Open,
+		ReadAll:   io.ReadAll,
+		PagerOpen: func([]render.Doc, pager.Options, io.Writer) error { t.Fatalf("nothing should be shown"); return nil },
+	}
+	if got := Run(cfg); got != exitcode.OK {
+		t.Fatalf("Run() = %d, want %d", got, exitcode.OK)
+	}
+	if !strings.Contains(stderr.String(), "a.out: binary file skipped") {
+		t.Fatalf("expected a skip warning, got %q", stderr.String())
+	}
+}
+
+func TestRunDetectsLanguageInsideCompressedFiles(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "data.json.gz")
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"a":1}`))
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var doc render.Doc
+	cfg := Config{
+		Args:      []string{path},
+		NoColor:   true,
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    io.Discard,
+		IsTTYIn:   func(*os.File) bool { return true },
+		IsTTYOut:  func(*os.File) bool { return true },
+		OpenFile:  os.Open,
+		ReadAll:   io.ReadAll,
+		PagerOpen: func(docs []render.Doc, _ pager.Options, _ io.Writer) error { doc = docs[0]; return nil },
+	}
+	if got := Run(cfg); got != exitcode.OK {
+		t.Fatalf("Run() = %d, want %d", got, exitcode.OK)
+	}
+	if doc.Kind != render.KindJSON || doc.Body != "{\n  \"a\": 1\n}\n" {
+		t.Fatalf("doc = %+v", doc)
+	}
+}
+
+func TestRunFollowTakesASingleFile(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "a.log"), []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{filepath.Join(tmp, "a.log"), filepath.Join(tmp, "a.log")},
+		{tmp},
+	} {
+		var stderr bytes.Buffer
+		cfg := Config{
+			Args:     args,
+			Follow:   true,
+			Stdin:    os.Stdin,
+			Stdout:   os.Stdout,
+			Stderr:   &stderr,
+			IsTTYIn:  func(*os.File) bool { return true },
+			IsTTYOut: func(*os.File) bool { return false },
+			OpenFile: os.Open,
+			ReadAll:  io.ReadAll,
+		}
+		if got := Run(cfg); got != exitcode.Usage {
+			t.Fatalf("Run(%q) = %d, want %d", args, got, exitcode.Usage)
+		}
+		if !strings.Contains(stderr.String(), "--follow takes a single file") {
+			t.Fatalf("stderr = %q", stderr.String())
+		}
+	}
+}
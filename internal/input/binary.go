@@ -0,0 +1,33 @@
+package input
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// binarySniffLen is how much of the input IsBinary looks at.
+const binarySniffLen = 8192
+
+// IsBinary reports whether data looks like binary content: it contains a
+// NUL byte, or more than a tenth of its bytes are not valid UTF-8.
+func IsBinary(data []byte) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	invalid := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			// A rune cut at the end of the sample is not evidence.
+			if len(data)-i < utf8.UTFMax && !utf8.FullRune(data[i:]) {
+				break
+			}
+			invalid++
+		}
+		i += size
+	}
+	return invalid*10 > len(data)
+}
@@ -0,0 +1,23 @@
+package input
+
+import "testing"
+
+func TestIsBinary(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"text", "hello, world\n", false},
+		{"utf8", "señor 日本語\n", false},
+		{"nul", "ELF\x00\x01", true},
+		{"latin1", "caf\xe9 au lait, cr\xe8me br\xfbl\xe9e and a long sentence\n", false},
+		{"garbage", "\xff\xfe\xfa\xfb\x80\x81ab", true},
+		{"cut rune", "abc\xe6\x97", false},
+	}
+	for _, c := range cases {
+		if got := IsBinary([]byte(c.data)); got != c.want {
+			t.Errorf("%s: IsBinary = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+type compression struct {
+	name   string
+	magic  []byte
+	suffix []string
+	reader func(io.Reader) (io.Reader, error)
+}
+
+var compressions = []compression{
+	{
+		name:   "gzip",
+		magic:  []byte{0x1f, 0x8b},
+		suffix: []string{".gz", ".gzip"},
+		reader: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	},
+	{
+		name:   "bzip2",
+		magic:  []byte("BZh"),
+		suffix: []string{".bz2", ".bz"},
+		reader: func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil },
+	},
+	{
+		name:   "zstd",
+		magic:  []byte{0x28, 0xb5, 0x2f, 0xfd},
+		suffix: []string{".zst", ".zstd"},
+		reader: func(r io.Reader) (io.Reader, error) {
+			d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	},
+	{
+		name:   "xz",
+		magic:  []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		suffix: []string{".xz"},
+		reader: func(r io.Reader) (io.Reader, error) { return xz.NewReader(r) },
+	},
+}
+
+// decompressReader detects a compressed stream by its magic bytes and
+// returns the name of its format with a reader of the decompressed data.
+// Data that is not compressed is read as is, with an empty format name.
+func decompressReader(r *bufio.Reader) (string, io.Reader, error) {
+	for _, c := range compressions {
+		if magic, _ := r.Peek(len(c.magic)); !bytes.Equal(magic, c.magic) {
+			continue
+		}
+		dr, err := c.reader(r)
+		if err != nil {
+			return c.name, nil, streamError(c.name, err)
+		}
+		return c.name, &decompressedReader{r: dr, format: c.name}, nil
+	}
+	return "", r, nil
+}
+
+// decompressedReader reports read errors in terms of the stream format.
+type decompressedReader struct {
+	r      io.Reader
+	format string
+}
+
+func (d *decompressedReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = streamError(d.format, err)
+	}
+	return n, err
+}
+
+func streamError(format string, err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return fmt.Errorf("truncated %s stream", format)
+	}
+	return fmt.Errorf("corrupt %s stream: %w", format, err)
+}
+
+// InnerName is the name of the source without the suffix of the format it
+// was compressed with, so "app.log.gz" gives "app.log". It is Name for
+// sources that were not compressed.
+func (s Source) InnerName() string {
+	for _, c := range compressions {
+		if c.name != s.Compression {
+			continue
+		}
+		lower := strings.ToLower(s.Name)
+		for _, suffix := range c.suffix {
+			if strings.HasSuffix(lower, suffix) {
+				return s.Name[:len(s.Name)-len(suffix)]
+			}
+		}
+	}
+	return s.Name
+}
@@ -0,0 +1,118 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const payload = "hello\n"
+
+// bzip2Hello is `printf 'hello\n' | bzip2`; the standard library has no
+// bzip2 encoder.
+const bzip2Hello = "425a6839314159265359c1c080e2000001410000100244a00030cd00c346" +
+	"2997177245385090c1c080e2"
+
+func compressed(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch format {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zstd":
+		w, err = zstd.NewWriter(&buf)
+	case "xz":
+		w, err = xz.NewWriter(&buf)
+	case "bzip2":
+		data, _ := hex.DecodeString(bzip2Hello)
+		return data
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, payload)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// decompress reads data through decompressReader.
+func decompress(data []byte) (string, []byte, error) {
+	format, r, err := decompressReader(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return format, nil, err
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return format, nil, err
+	}
+	return format, out, nil
+}
+
+func TestDecompressFormats(t *testing.T) {
+	for _, format := range []string{"gzip", "bzip2", "zstd", "xz"} {
+		got, data, err := decompress(compressed(t, format))
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if got != format || string(data) != payload {
+			t.Fatalf("%s: decompress = %q, %q", format, got, data)
+		}
+	}
+}
+
+func TestDecompressPlainData(t *testing.T) {
+	format, data, err := decompress([]byte(payload))
+	if err != nil || format != "" || string(data) != payload {
+		t.Fatalf("decompress = %q, %q, %v", format, data, err)
+	}
+}
+
+func TestDecompressTruncatedAndCorrupt(t *testing.T) {
+	for _, format := range []string{"gzip", "bzip2", "zstd", "xz"} {
+		data := compressed(t, format)
+		_, _, err := decompress(data[:len(data)-4])
+		if err == nil || !strings.Contains(err.Error(), format) {
+			t.Fatalf("%s: truncated stream gave %v", format, err)
+		}
+
+		corrupt := bytes.Clone(data)
+		for i := len(corrupt) - 4; i < len(corrupt); i++ {
+			corrupt[i] ^= 0x55
+		}
+		if _, _, err := decompress(corrupt); err == nil {
+			t.Fatalf("%s: corrupt stream was accepted", format)
+		}
+	}
+	data := compressed(t, "gzip")
+	if _, _, err := decompress(data[:len(data)/2]); err == nil || err.Error() != "truncated gzip stream" {
+		t.Fatalf("half a gzip stream gave %v", err)
+	}
+}
+
+func TestInnerName(t *testing.T) {
+	cases := []struct {
+		src  Source
+		want string
+	}{
+		{Source{Name: "logs/app.log.gz", Compression: "gzip"}, "logs/app.log"},
+		{Source{Name: "data.JSON.ZST", Compression: "zstd"}, "data.JSON"},
+		{Source{Name: "app.log.gz"}, "app.log.gz"},
+		{Source{Name: "stdin", Compression: "xz"}, "stdin"},
+	}
+	for _, c := range cases {
+		if got := c.src.InnerName(); got != c.want {
+			t.Errorf("InnerName(%q) = %q, want %q", c.src.Name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package input
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func followOne(t *testing.T, path string) Source {
+	t.Helper()
+	old := followInterval
+	followInterval = 5 * time.Millisecond
+	t.Cleanup(func() { followInterval = old })
+	res, _, err := Load([]string{path}, LoadOptions{Follow: true}, os.Stdin, func(*os.File) bool { return true }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := res.Sources[0]
+	if src.Rest == nil || !src.Rest.Following() {
+		t.Fatalf("expected a followed stream, got %+v", src.Rest)
+	}
+	t.Cleanup(func() { src.Rest.Close() })
+	return src
+}
+
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// nextLines reads the next block of a followed stream, failing if none
+// arrives in time.
+func nextLines(t *testing.T, s *Stream) string {
+	t.Helper()
+	got := make(chan string, 1)
+	go func() {
+		data, _, _ := s.Next()
+		got <- string(data)
+	}()
+	select {
+	case data := <-got:
+		return data
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for appended lines")
+		return ""
+	}
+}
+
+func TestFollowReadsAppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("one\ntw"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := followOne(t, path)
+	if string(src.Data) != "one\n" {
+		t.Fatalf("Data = %q, want the complete lines only", src.Data)
+	}
+
+	appendFile(t, path, "o\nthree\n")
+	if got := nextLines(t, src.Rest); got != "two\nthree\n" {
+		t.Fatalf("Next = %q", got)
+	}
+}
+
+func TestFollowReopensTruncatedAndRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("old line one\nold line two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := followOne(t, path)
+
+	if err := os.WriteFile(path, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := nextLines(t, src.Rest); got != "new\n" {
+		t.Fatalf("after truncation Next = %q", got)
+	}
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("rotated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := nextLines(t, src.Rest); got != "rotated\n" {
+		t.Fatalf("after rotation Next = %q", got)
+	}
+}
+
+func TestFollowRejectsCompressedInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.log.gz")
+	if err := os.WriteFile(path, compressed(t, "gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, _, err := Load([]string{path}, LoadOptions{Follow: true}, os.Stdin, func(*os.File) bool { return true }, os.Open, io.ReadAll)
+	if err == nil || len(res.Errors) != 1 {
+		t.Fatalf("expected compressed input to be rejected, got %v %v", err, res.Errors)
+	}
+}
+
+func TestFollowShowsALastLineWithoutNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.json")
+	if err := os.WriteFile(path, []byte(`{"a":1`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := followOne(t, path)
+	if string(src.Data) != `{"a":1` {
+		t.Fatalf("Data = %q, want the incomplete line at once", src.Data)
+	}
+
+	appendFile(t, path, "}\nnext")
+	if got := nextLines(t, src.Rest); got != "{\"a\":1}\n" {
+		t.Fatalf("Next = %q, want the line joined with what was appended", got)
+	}
+	if got := nextLines(t, src.Rest); got != "next" {
+		t.Fatalf("Next = %q, want the new incomplete line", got)
+	}
+}
@@ -10,6 +10,16 @@ type Source struct {
 	Name    string
 	Data    []byte
 	IsStdin bool
+	// Compression is the format Data was decompressed from, "" if it was
+	// not compressed.
+	Compression string
+	// IsDir marks a directory argument loaded without recursion. Entries
+	// lists its contents relative to it, directories with a trailing slash.
+	IsDir   bool
+	Entries []string
+	// Rest is set when the source is larger than one block. Data then only
+	// holds the first block, cut at a line boundary, and Rest the remainder.
+	Rest *Stream
 }
 
 type LoadResult struct {
@@ -21,7 +31,21 @@ type FileOpener func(name string) (*os.File, error)
 type ReadAllFn func(r io.Reader) ([]byte, error)
 type IsTTYFn func(*os.File) bool
 
-func Load(args []string, stdin *os.File, isTTY IsTTYFn, openFile FileOpener, readAll ReadAllFn) (LoadResult, bool, error) {
+// LoadOptions changes how Load reads its arguments.
+type LoadOptions struct {
+	// Recursive loads every text file inside directory arguments instead of
+	// a listing of their tree.
+	Recursive bool
+	// Follow keeps every source open as a Stream that reads what is
+	// appended to it later.
+	Follow bool
+}
+
+// Load reads the files named by args, or stdin when there are none. Args
+// with glob metacharacters that do not name a file are expanded with Glob.
+// A directory is loaded as a listing of its tree, or as every text file
+// inside it.
+func Load(args []string, opts LoadOptions, stdin *os.File, isTTY IsTTYFn, openFile FileOpener, readAll ReadAllFn) (LoadResult, bool, error) {
 	result := LoadResult{}
 	stdinHasData := !isTTY(stdin)
 
@@ -29,27 +53,27 @@ func Load(args []string, stdin *os.File, isTTY IsTTYFn, openFile FileOpener, rea
 		if !stdinHasData {
 			return result, false, fmt.Errorf("no input: provide a file or pipe data through stdin")
 		}
-		data, err := readAll(stdin)
+		data, format, rest, err := openSource(stdin, "", opts.Follow, nil, readAll)
 		if err != nil {
 			return result, false, fmt.Errorf("read stdin: %w", err)
 		}
-		result.Sources = append(result.Sources, Source{Name: "stdin", Data: data, IsStdin: true})
+		result.Sources = append(result.Sources, Source{Name: "stdin", Data: data, IsStdin: true, Compression: format, Rest: rest})
 		return result, false, nil
 	}
 
-	for _, path := range args {
-		f, err := openFile(path)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", path, err))
+	for _, arg := range args {
+		if !isGlob(arg) {
+			result.load(arg, opts, false, openFile, readAll)
 			continue
 		}
-		data, err := readAll(f)
-		f.Close()
+		matches, err := Glob(arg)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", path, err))
+			result.Errors = append(result.Errors, err)
 			continue
 		}
-		result.Sources = append(result.Sources, Source{Name: path, Data: data, IsStdin: false})
+		for _, path := range matches {
+			result.load(path, LoadOptions{Follow: opts.Follow}, false, openFile, readAll)
+		}
 	}
 
 	if len(result.Sources) == 0 {
@@ -58,3 +82,40 @@ func Load(args []string, stdin *os.File, isTTY IsTTYFn, openFile FileOpener, rea
 
 	return result, stdinHasData, nil
 }
+
+// load reads path into a source. With skipBinary, binary files are dropped
+// silently; they are only expected when walking a directory.
+func (r *LoadResult) load(path string, opts LoadOptions, skipBinary bool, openFile FileOpener, readAll ReadAllFn) {
+	f, err := openFile(path)
+	if err != nil {
+		r.Errors = append(r.Errors, fmt.Errorf("%s: %w", path, err))
+		return
+	}
+	if fi, err := f.Stat(); err == nil && fi.IsDir() {
+		f.Close()
+		if opts.Recursive {
+			files, errs := walkFiles(path)
+			r.Errors = append(r.Errors, errs...)
+			for _, file := range files {
+				r.load(file, LoadOptions{Follow: opts.Follow}, true, openFile, readAll)
+			}
+			return
+		}
+		entries, errs := listTree(path)
+		r.Errors = append(r.Errors, errs...)
+		r.Sources = append(r.Sources, Source{Name: path, IsDir: true, Entries: entries})
+		return
+	}
+	data, format, rest, err := openSource(f, path, opts.Follow, openFile, readAll)
+	if err != nil {
+		r.Errors = append(r.Errors, fmt.Errorf("%s: %w", path, err))
+		return
+	}
+	if skipBinary && IsBinary(data) {
+		if rest != nil {
+			rest.Close()
+		}
+		return
+	}
+	r.Sources = append(r.Sources, Source{Name: path, Data: data, Compression: format, Rest: rest})
+}
@@ -0,0 +1,405 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// blockSize is how much of a source is read at a time. Blocks are extended
+// to the end of their last line.
+var blockSize = 256 << 10
+
+// followInterval is how often a followed file is checked for new data.
+var followInterval = 250 * time.Millisecond
+
+// sniffWait is how long the first block of a pipe waits for sniffLines
+// lines, so that its kind is not judged by the first write alone.
+var sniffWait = 500 * time.Millisecond
+
+const sniffLines = 10
+
+// Stream is the unread remainder of a source larger than one block, or of
+// any followed source.
+type Stream struct {
+	r        *bufio.Reader // nil while the file is closed between reads
+	closer   io.Closer
+	file     *os.File // the open file when offsets are offsets into it
+	seekable bool
+	offset   int64
+	readAll  ReadAllFn
+	path     string // reopened to read on, set for files only
+	open     FileOpener
+	pipe     bool       // reads may block until the writer sends more
+	ahead    chan chunk // a pipe read that outlasted its wait
+	partial  []byte     // an incomplete last line
+	follow   *follower
+}
+
+// follower is the state of a Stream that keeps reading after the end of
+// its source, like tail -f.
+type follower struct {
+	file  *os.File
+	path  string // reopened when the file is replaced, "" for stdin
+	wait  bool   // false for pipes, whose end is final
+	shown int    // bytes of partial already returned
+}
+
+// openSource reads the first block of f, decompressing it if needed. The
+// returned stream is nil when that block holds the whole source, unless
+// follow is set. f is closed once it is no longer needed, unless it is
+// stdin, which has an empty path. A file that is not followed is also
+// closed after the first block, so that loading many large files does not
+// hold a descriptor for each; the stream reopens it with open when it is
+// read on.
+func openSource(f *os.File, path string, follow bool, open FileOpener, readAll ReadAllFn) ([]byte, string, *Stream, error) {
+	var closer io.Closer = f
+	if path == "" {
+		closer = io.NopCloser(nil)
+	}
+	base, seekErr := f.Seek(0, io.SeekCurrent)
+	format, r, err := decompressReader(bufio.NewReaderSize(f, 64<<10))
+	if err == nil && follow && format != "" {
+		err = fmt.Errorf("cannot follow %s data", format)
+	}
+	if err != nil {
+		closer.Close()
+		return nil, format, nil, err
+	}
+	fi, statErr := f.Stat()
+	regular := statErr == nil && fi.Mode().IsRegular()
+	s := &Stream{r: bufio.NewReaderSize(r, 64<<10), closer: closer, offset: base, readAll: readAll, pipe: !regular}
+
+	if follow {
+		s.follow = &follower{file: f, path: path, wait: regular}
+		data, _, err := s.appended(false)
+		if err != nil && err != io.EOF {
+			closer.Close()
+			return nil, format, nil, err
+		}
+		return data, format, s, nil
+	}
+
+	if regular && format == "" && seekErr == nil {
+		s.file, s.seekable = f, true
+	}
+	data, _, err := s.Next()
+	if s.pipe {
+		wait := time.After(sniffWait)
+		for err == nil && len(data) < blockSize && bytes.Count(data, []byte("\n")) < sniffLines {
+			var more []byte
+			more, _, err = s.lines(wait)
+			if more == nil && err == nil {
+				break
+			}
+			data = append(data, more...)
+		}
+	}
+	switch {
+	case err == io.EOF:
+		closer.Close()
+		return data, format, nil, nil
+	case err != nil:
+		closer.Close()
+		return nil, format, nil, err
+	}
+	if regular && path != "" && base == 0 {
+		s.path, s.open = path, open
+		s.park()
+	}
+	return data, format, s, nil
+}
+
+// park closes the file until the stream is read again.
+func (s *Stream) park() error {
+	err := s.closer.Close()
+	s.r, s.file, s.closer = nil, nil, io.NopCloser(nil)
+	return err
+}
+
+// resume reopens a parked file at the offset it was left at. Compressed
+// files are decompressed again up to there.
+func (s *Stream) resume() error {
+	if s.r != nil || s.open == nil {
+		return nil
+	}
+	f, err := s.open(s.path)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = f
+	if s.seekable {
+		_, err = f.Seek(s.offset, io.SeekStart)
+	} else if _, r, err = decompressReader(bufio.NewReaderSize(f, 64<<10)); err == nil {
+		_, err = io.CopyN(io.Discard, r, s.offset)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.r, s.closer = bufio.NewReaderSize(r, 64<<10), f
+	if s.seekable {
+		s.file = f
+	}
+	return nil
+}
+
+// Next returns the next block of whole lines and the offset it starts at.
+// It returns io.EOF, possibly together with a final block, at the end.
+//
+// Pipes return the lines of a single read instead of a full block, so that
+// lines are shown as soon as a slow writer sends them. Followed streams
+// return whatever complete lines were appended, waiting for them when
+// there are none.
+func (s *Stream) Next() ([]byte, int64, error) {
+	switch {
+	case s.follow != nil:
+		return s.appended(true)
+	case s.pipe:
+		return s.lines(nil)
+	}
+	offset := s.offset
+	if err := s.resume(); err != nil {
+		return nil, offset, err
+	}
+	data := make([]byte, blockSize)
+	n, err := io.ReadFull(s.r, data)
+	data = data[:n]
+	if err == nil {
+		var line []byte
+		line, err = s.r.ReadBytes('\n')
+		data = append(data, line...)
+	}
+	s.offset += int64(len(data))
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	if err == io.EOF && len(data) == 0 {
+		return nil, offset, io.EOF
+	}
+	if err == nil {
+		// Report the end with the last block when it is already known.
+		if _, peekErr := s.r.Peek(1); peekErr == io.EOF {
+			err = io.EOF
+		}
+	}
+	return data, offset, err
+}
+
+// chunk is the result of one read.
+type chunk struct {
+	data []byte
+	err  error
+}
+
+// read reads the pipe, giving up when timeout fires first. The read goes
+// on, and the next call returns its chunk.
+func (s *Stream) read(timeout <-chan time.Time) (chunk, bool) {
+	if s.ahead == nil {
+		ahead := make(chan chunk, 1)
+		go func() {
+			buf := make([]byte, blockSize)
+			n, err := s.r.Read(buf)
+			ahead <- chunk{buf[:n], err}
+		}()
+		s.ahead = ahead
+	}
+	select {
+	case c := <-s.ahead:
+		s.ahead = nil
+		return c, true
+	case <-timeout:
+		return chunk{}, false
+	}
+}
+
+// lines returns the complete lines of the next read, reading again only
+// while there are none, or nothing when timeout fires first. The
+// incomplete last line is kept for the next call and returned with io.EOF
+// at the end.
+func (s *Stream) lines(timeout <-chan time.Time) ([]byte, int64, error) {
+	offset := s.offset
+	for {
+		c, ok := s.read(timeout)
+		if !ok {
+			return nil, offset, nil
+		}
+		s.partial = append(s.partial, c.data...)
+		var data []byte
+		switch i := bytes.LastIndexByte(s.partial, '\n'); {
+		case c.err != nil:
+			data, s.partial = s.partial, nil
+		case i >= 0:
+			data = s.partial[:i+1]
+			s.partial = bytes.Clone(s.partial[i+1:])
+		default:
+			continue
+		}
+		s.offset += int64(len(data))
+		return data, offset, c.err
+	}
+}
+
+// appended returns the complete lines read past the last call. With wait
+// it polls the file until there are some, reopening it when it is
+// truncated or replaced; a pipe is read until its writer closes it.
+//
+// A last line without a newline is returned as soon as the end of the
+// file is reached, like tail -f shows it. It is kept and returned again,
+// joined with what is appended to it, once it is complete.
+func (s *Stream) appended(wait bool) ([]byte, int64, error) {
+	f := s.follow
+	buf := make([]byte, blockSize)
+	for {
+		n, err := s.r.Read(buf)
+		s.partial = append(s.partial, buf[:n]...)
+		if i := bytes.LastIndexByte(s.partial, '\n'); i >= 0 {
+			data := s.partial[:i+1]
+			s.partial = bytes.Clone(s.partial[i+1:])
+			f.shown = 0
+			offset := s.offset
+			s.offset += int64(len(data))
+			return data, offset, nil
+		}
+		switch {
+		case n > 0 && err == nil:
+			continue
+		case err != nil && err != io.EOF:
+			return nil, s.offset, err
+		case !f.wait:
+			if err == nil {
+				continue
+			}
+			data := s.partial
+			s.partial = nil
+			s.offset += int64(len(data))
+			return data, s.offset - int64(len(data)), io.EOF
+		case len(s.partial) > f.shown:
+			f.shown = len(s.partial)
+			return bytes.Clone(s.partial), s.offset, nil
+		case !wait:
+			return nil, s.offset, nil
+		}
+		time.Sleep(followInterval)
+		s.reopen()
+	}
+}
+
+// reopen starts over when the followed file shrank, and switches to the
+// file now at its path when it was rotated.
+func (s *Stream) reopen() {
+	f := s.follow
+	fi, err := f.file.Stat()
+	if err != nil {
+		return
+	}
+	if fi.Size() < s.offset+int64(len(s.partial)) {
+		if _, err := f.file.Seek(0, io.SeekStart); err == nil {
+			s.restart(f.file)
+		}
+		return
+	}
+	if f.path == "" {
+		return
+	}
+	if pi, err := os.Stat(f.path); err != nil || os.SameFile(fi, pi) {
+		return
+	}
+	nf, err := os.Open(f.path)
+	if err != nil {
+		return
+	}
+	f.file.Close()
+	f.file, s.closer = nf, nf
+	s.restart(nf)
+}
+
+func (s *Stream) restart(file *os.File) {
+	s.r = bufio.NewReaderSize(file, 64<<10)
+	s.offset = 0
+	s.partial = nil
+	s.follow.shown = 0
+}
+
+// Following reports whether the stream reads data appended to its source.
+func (s *Stream) Following() bool {
+	return s.follow != nil
+}
+
+// Waits reports whether Next may wait for a writer: the stream follows
+// its source or reads a pipe.
+func (s *Stream) Waits() bool {
+	return s.follow != nil || s.pipe
+}
+
+// Seekable reports whether ReadAt can read blocks again.
+func (s *Stream) Seekable() bool {
+	return s.seekable
+}
+
+// ReadAt reads the source bytes at off. It only works on seekable streams,
+// and opens the file for the read when it is parked or closed.
+func (s *Stream) ReadAt(p []byte, off int64) (int, error) {
+	switch {
+	case !s.seekable:
+		return 0, errors.New("stream is not seekable")
+	case s.file != nil:
+		return s.file.ReadAt(p, off)
+	}
+	f, err := s.open(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.ReadAt(p, off)
+}
+
+func (s *Stream) Close() error {
+	if s.open != nil {
+		return s.park()
+	}
+	return s.closer.Close()
+}
+
+// rest reads what is left of the stream, including the line and the pipe
+// read it has started.
+func (s *Stream) rest() ([]byte, error) {
+	data := s.partial
+	if s.ahead != nil {
+		c := <-s.ahead
+		s.ahead = nil
+		data = append(data, c.data...)
+		switch {
+		case c.err == io.EOF:
+			return data, nil
+		case c.err != nil:
+			return data, c.err
+		}
+	}
+	rest, err := s.readAll(s.r)
+	return append(data, rest...), err
+}
+
+// ReadAll reads the rest of the source into Data. A followed source is only
+// read up to its current end.
+func (src *Source) ReadAll() error {
+	if src.Rest == nil {
+		return nil
+	}
+	defer src.Rest.Close()
+	if err := src.Rest.resume(); err != nil {
+		src.Rest = nil
+		return fmt.Errorf("%s: %w", src.Name, err)
+	}
+	rest, err := src.Rest.rest()
+	src.Rest = nil
+	if err != nil {
+		return fmt.Errorf("%s: %w", src.Name, err)
+	}
+	src.Data = append(src.Data, rest...)
+	return nil
+}
@@ -0,0 +1,254 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func smallBlocks(t *testing.T, size int) {
+	t.Helper()
+	old := blockSize
+	blockSize = size
+	t.Cleanup(func() { blockSize = old })
+}
+
+func numberedText(lines int) string {
+	var b strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func loadOne(t *testing.T, path string) Source {
+	t.Helper()
+	res, _, err := Load([]string{path}, LoadOptions{}, os.Stdin, func(*os.File) bool { return true }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Sources[0]
+}
+
+func TestStreamReadsBlocksOfWholeLines(t *testing.T) {
+	smallBlocks(t, 64)
+	text := numberedText(100)
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := loadOne(t, path)
+	if src.Rest == nil || !src.Rest.Seekable() {
+		t.Fatalf("expected a seekable stream, got %+v", src.Rest)
+	}
+	defer src.Rest.Close()
+	got := string(src.Data)
+	for {
+		data, offset, err := src.Rest.Next()
+		if !bytes.HasSuffix(data, []byte("\n")) && len(data) > 0 {
+			t.Fatalf("block %q does not end a line", data)
+		}
+		if int(offset) != len(got) {
+			t.Fatalf("offset = %d, want %d", offset, len(got))
+		}
+		again := make([]byte, len(data))
+		if _, err := src.Rest.ReadAt(again, offset); err != nil || !bytes.Equal(again, data) {
+			t.Fatalf("ReadAt(%d) = %q, %v", offset, again, err)
+		}
+		got += string(data)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got != text {
+		t.Fatalf("streamed text differs from the file")
+	}
+}
+
+func TestSourceReadAllJoinsCompressedStream(t *testing.T) {
+	smallBlocks(t, 64)
+	text := numberedText(100)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	io.WriteString(zw, text)
+	zw.Close()
+	path := filepath.Join(t.TempDir(), "a.txt.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := loadOne(t, path)
+	if src.Rest == nil || src.Rest.Seekable() {
+		t.Fatalf("expected a stream that cannot seek, got %+v", src.Rest)
+	}
+	if err := src.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	if src.Rest != nil || string(src.Data) != text {
+		t.Fatalf("ReadAll left %d bytes, rest %v", len(src.Data), src.Rest)
+	}
+}
+
+func TestSmallSourcesAreNotStreamed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if src := loadOne(t, path); src.Rest != nil || string(src.Data) != "hello\n" {
+		t.Fatalf("source = %+v", src)
+	}
+}
+
+func TestPipesReturnLinesAsTheyArrive(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	io.WriteString(w, "one\ntw")
+
+	loaded := make(chan Source, 1)
+	go func() {
+		res, _, err := Load(nil, LoadOptions{}, r, func(*os.File) bool { return false }, os.Open, io.ReadAll)
+		if err != nil {
+			t.Error(err)
+		}
+		loaded <- res.Sources[0]
+	}()
+	var src Source
+	select {
+	case src = <-loaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Load waited for the pipe to fill a block")
+	}
+	if string(src.Data) != "one\n" || src.Rest == nil {
+		t.Fatalf("Data = %q, Rest = %v", src.Data, src.Rest)
+	}
+
+	io.WriteString(w, "o\nthree")
+	w.Close()
+	data, offset, err := src.Rest.Next()
+	if string(data) != "two\n" || offset != 4 || err != nil {
+		t.Fatalf("Next = %q, %d, %v", data, offset, err)
+	}
+	data, offset, err = src.Rest.Next()
+	if string(data) != "three" || offset != 8 || err != io.EOF {
+		t.Fatalf("last Next = %q, %d, %v", data, offset, err)
+	}
+}
+
+func TestLargeFilesAreClosedBetweenReads(t *testing.T) {
+	smallBlocks(t, 64)
+	text := numberedText(100)
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt.gz"} {
+		data := []byte(text)
+		if strings.HasSuffix(name, ".gz") {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write(data)
+			zw.Close()
+			data = buf.Bytes()
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	var opened []*os.File
+	open := func(name string) (*os.File, error) {
+		f, err := os.Open(name)
+		opened = append(opened, f)
+		return f, err
+	}
+	stillOpen := func() int {
+		n := 0
+		for _, f := range opened {
+			if _, err := f.Stat(); err == nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	res, _, err := Load(paths, LoadOptions{}, os.Stdin, func(*os.File) bool { return true }, open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := stillOpen(); n != 0 {
+		t.Fatalf("%d files left open after loading", n)
+	}
+	for _, src := range res.Sources {
+		if src.Rest == nil {
+			t.Fatalf("%s: expected a stream", src.Name)
+		}
+		got := string(src.Data)
+		for {
+			data, offset, err := src.Rest.Next()
+			if src.Rest.Seekable() {
+				again := make([]byte, len(data))
+				src.Rest.ReadAt(again, offset)
+				if !bytes.Equal(again, data) {
+					t.Fatalf("%s: ReadAt(%d) = %q, want %q", src.Name, offset, again, data)
+				}
+			}
+			got += string(data)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		src.Rest.Close()
+		if got != text {
+			t.Fatalf("%s: streamed text differs from the file", src.Name)
+		}
+	}
+	if n := stillOpen(); n != 0 {
+		t.Fatalf("%d files left open after reading", n)
+	}
+}
+
+func TestPipesWaitBrieflyForLinesToSniff(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	go func() {
+		io.WriteString(w, "{\"msg\":\"a\"}\n")
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, "{\"msg\":\"b\"}\n")
+	}()
+	res, _, err := Load(nil, LoadOptions{}, r, func(*os.File) bool { return false }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := res.Sources[0]
+	if string(src.Data) != "{\"msg\":\"a\"}\n{\"msg\":\"b\"}\n" || src.Rest == nil {
+		t.Fatalf("Data = %q, Rest = %v", src.Data, src.Rest)
+	}
+
+	// The read still waiting when sniffing gave up is not lost.
+	io.WriteString(w, "tail\n")
+	w.Close()
+	if err := src.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(src.Data), "}\ntail\n") {
+		t.Fatalf("Data = %q", src.Data)
+	}
+}
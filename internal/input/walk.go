@@ -0,0 +1,221 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ignoreRule is one pattern from a .gitignore file. base is the directory
+// of that file relative to the walk root, "" for the root itself.
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+type ignorer struct {
+	rules []ignoreRule
+}
+
+// load adds the rules of dir/.gitignore, if there is one.
+func (ig *ignorer) load(dir, base string) {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		ig.rules = append(ig.rules, rule)
+	}
+}
+
+// ignored reports whether rel, a slash-separated path relative to the walk
+// root, is excluded. The last matching rule wins, as in git.
+func (ig *ignorer) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range ig.rules {
+		name := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			name = rel[len(r.base)+1:]
+		}
+		if r.dirOnly && !isDir {
+			continue
+		}
+		var match bool
+		if r.anchored {
+			match = matchPath(r.pattern, name)
+		} else {
+			match, _ = path.Match(r.pattern, path.Base(name))
+		}
+		if match {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// walk visits everything under root in lexical order, skipping hidden
+// entries and what .gitignore files exclude. rel is slash-separated and
+// relative to root. Unreadable directories are reported and skipped.
+func walk(root string, visit func(rel string, isDir bool)) []error {
+	ig := &ignorer{}
+	var errs []error
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			ig.load(p, "")
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || ig.ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			ig.load(p, rel)
+		}
+		visit(rel, d.IsDir())
+		return nil
+	})
+	return errs
+}
+
+// walkFiles returns the paths of the regular files under dir.
+func walkFiles(dir string) ([]string, []error) {
+	var files []string
+	errs := walk(dir, func(rel string, isDir bool) {
+		if !isDir {
+			files = append(files, filepath.Join(dir, filepath.FromSlash(rel)))
+		}
+	})
+	return files, errs
+}
+
+// listTree returns the entries under dir relative to it, directories with a
+// trailing slash.
+func listTree(dir string) ([]string, []error) {
+	var entries []string
+	errs := walk(dir, func(rel string, isDir bool) {
+		if isDir {
+			rel += "/"
+		}
+		entries = append(entries, rel)
+	})
+	return entries, errs
+}
+
+// isGlob reports whether arg should be expanded as a pattern: it has glob
+// metacharacters and does not name an existing file.
+func isGlob(arg string) bool {
+	if !strings.ContainsAny(arg, "*?[") {
+		return false
+	}
+	_, err := os.Lstat(arg)
+	return err != nil
+}
+
+// Glob expands pattern like a shell with globstar: "**" matches any number
+// of directories. Hidden and git-ignored files are left out, and only
+// regular files are returned, sorted.
+func Glob(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(pattern)
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return nil, fmt.Errorf("%s: bad pattern", pattern)
+	}
+
+	segs := strings.Split(pattern, "/")
+	static := 0
+	for static < len(segs)-1 && !strings.ContainsAny(segs[static], "*?[") {
+		static++
+	}
+	root := strings.Join(segs[:static], "/")
+	rest := strings.Join(segs[static:], "/")
+	if root == "" && strings.HasPrefix(pattern, "/") {
+		root = "/"
+	}
+	if root == "" {
+		root = "."
+	}
+
+	var matches []string
+	walk(filepath.FromSlash(root), func(rel string, isDir bool) {
+		if !isDir && matchPath(rest, rel) {
+			matches = append(matches, filepath.FromSlash(path.Join(root, rel)))
+		}
+	})
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%s: no matching files", pattern)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchPath matches a slash-separated name against a pattern whose "**"
+// segments match zero or more path segments.
+func matchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,119 @@
+package input
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/main.go", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**", "a/x/y", true},
+		{"*.go", "a/main.go", false},
+		{"a/*/b", "a/x/y/b", false},
+	}
+	for _, c := range cases {
+		if got := matchPath(c.pattern, c.name); got != c.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestListTreeRespectsGitignore(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":       "build/\n*.log\n!keep.log\n",
+		"a.log":            "",
+		"keep.log":         "",
+		"build/out":        "",
+		"src/main.go":      "",
+		"src/.gitignore":   "gen_*.go\n",
+		"src/gen_x.go":     "",
+		"src/sub/gen_y.go": "",
+		".git/config":      "",
+	})
+	entries, errs := listTree(root)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	want := []string{"keep.log", "src/", "src/main.go", "src/sub/"}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("listTree = %q, want %q", entries, want)
+	}
+}
+
+func TestLoadDirectories(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.txt":     "a\n",
+		"b/c.md":    "# c\n",
+		"b/bin.dat": "\x00\x01",
+	})
+	stdin, _ := os.Open(os.DevNull)
+	defer stdin.Close()
+	tty := func(*os.File) bool { return true }
+
+	res, _, err := Load([]string{root}, LoadOptions{}, stdin, tty, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Sources) != 1 || !res.Sources[0].IsDir || len(res.Sources[0].Entries) != 4 {
+		t.Fatalf("directory without -r = %+v", res.Sources)
+	}
+
+	res, _, err = Load([]string{root}, LoadOptions{Recursive: true}, stdin, tty, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, src := range res.Sources {
+		names = append(names, src.Name)
+	}
+	want := []string{filepath.Join(root, "a.txt"), filepath.Join(root, "b", "c.md")}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("recursive load = %q, want %q", names, want)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":        "",
+		"pkg/a/a.go":     "",
+		"pkg/a/a.txt":    "",
+		"pkg/b/c/c.go":   "",
+		"pkg/.hide/h.go": "",
+	})
+	got, err := Glob(filepath.Join(root, "pkg", "**", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(root, "pkg", "a", "a.go"), filepath.Join(root, "pkg", "b", "c", "c.go")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Glob = %q, want %q", got, want)
+	}
+	if _, err := Glob(filepath.Join(root, "**", "*.rs")); err == nil {
+		t.Fatal("expected an error when nothing matches")
+	}
+}
@@ -0,0 +1,215 @@
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+var autolinkPrefixes = []string{"www.", "http://", "https://", "ftp://"}
+
+// autolinkCandidate reports whether an extended autolink may start at s[i]:
+// it must follow whitespace, one of "*_~(" or the start of the text.
+func autolinkCandidate(s string, i int) bool {
+	if i > 0 && strings.IndexByte(" \t\n*_~(", s[i-1]) < 0 {
+		return false
+	}
+	return autolinkPrefix(s[i:]) != ""
+}
+
+func autolinkPrefix(s string) string {
+	for _, prefix := range autolinkPrefixes {
+		if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// parseExtendedAutolink handles the GFM www. and scheme autolinks.
+func (ip *inlineParser) parseExtendedAutolink(block *Node) bool {
+	if !ip.gfm || !autolinkCandidate(ip.subject, ip.pos) {
+		return false
+	}
+	rest := ip.subject[ip.pos:]
+	prefix := autolinkPrefix(rest)
+	domainStart := len(prefix)
+	if prefix == "www." {
+		domainStart = 0
+	}
+
+	domainEnd := domainStart
+	for domainEnd < len(rest) {
+		r, size := utf8.DecodeRuneInString(rest[domainEnd:])
+		if !isDomainRune(r) {
+			break
+		}
+		domainEnd += size
+	}
+	if !validDomain(rest[domainStart:domainEnd]) {
+		return false
+	}
+
+	end := domainEnd
+	for end < len(rest) && rest[end] != '<' && !isUnicodeSpace(rune(rest[end])) && rest[end] != ' ' {
+		end++
+	}
+	end = trimAutolinkEnd(rest[:end])
+	if end <= domainStart {
+		return false
+	}
+
+	literal := rest[:end]
+	dest := literal
+	if prefix == "www." {
+		dest = "http://" + literal
+	}
+	link := &Node{Type: Link, Destination: dest}
+	link.AppendChild(text(literal))
+	block.AppendChild(link)
+	ip.pos += end
+	return true
+}
+
+func isDomainRune(r rune) bool {
+	return r == '.' || r == '-' || r == '_' || r >= utf8.RuneSelf ||
+		(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func validDomain(domain string) bool {
+	domain = strings.TrimRight(domain, ".")
+	segments := strings.Split(domain, ".")
+	if len(segments) < 2 {
+		return false
+	}
+	for i, seg := range segments {
+		if seg == "" {
+			return false
+		}
+		if i >= len(segments)-2 && strings.Contains(seg, "_") {
+			return false
+		}
+	}
+	return true
+}
+
+// trimAutolinkEnd drops trailing punctuation, unbalanced closing parens and
+// entity-like suffixes, returning the new length of link.
+func trimAutolinkEnd(link string) int {
+	for {
+		n := len(link)
+		if n == 0 {
+			return 0
+		}
+		switch c := link[n-1]; {
+		case strings.IndexByte("?!.,:*_~'\"", c) >= 0:
+			link = link[:n-1]
+		case c == ')' && strings.Count(link, ")") > strings.Count(link, "("):
+			link = link[:n-1]
+		case c == ';':
+			amp := strings.LastIndexByte(link, '&')
+			if amp < 0 || !isAlnum(link[amp+1:n-1]) {
+				return n
+			}
+			link = link[:amp]
+		default:
+			return n
+		}
+	}
+}
+
+func isAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// linkifyEmails turns bare email addresses in text nodes into mailto links.
+func linkifyEmails(n *Node) {
+	for c := n.FirstChild; c != nil; c = c.Next {
+		switch c.Type {
+		case Link, Image:
+			continue
+		case Text:
+			c = splitEmails(c)
+		default:
+			linkifyEmails(c)
+		}
+	}
+}
+
+// splitEmails replaces t with text and link nodes and returns the last node
+// it inserted.
+func splitEmails(t *Node) *Node {
+	s := t.Literal
+	last := t
+	pos := 0
+	first := true
+	for {
+		at := strings.IndexByte(s[pos:], '@')
+		if at < 0 {
+			break
+		}
+		at += pos
+		start := at
+		for start > pos && isEmailLocal(s[start-1]) {
+			start--
+		}
+		end := at + 1
+		for end < len(s) && isEmailDomain(s[end]) {
+			end++
+		}
+		for end > at+1 && s[end-1] == '.' {
+			end--
+		}
+		domain := s[at+1 : end]
+		if start == at || !strings.Contains(domain, ".") || strings.HasSuffix(domain, "-") || strings.HasSuffix(domain, "_") ||
+			(end < len(s) && (s[end] == '-' || s[end] == '_')) {
+			pos = at + 1
+			continue
+		}
+
+		before := s[:start]
+		addr := s[start:end]
+		if first {
+			t.Literal = before
+			first = false
+		} else if before != "" {
+			node := text(before)
+			last.insertAfter(node)
+			last = node
+		}
+		link := &Node{Type: Link, Destination: "mailto:" + addr}
+		link.AppendChild(text(addr))
+		last.insertAfter(link)
+		last = link
+		s = s[end:]
+		pos = 0
+	}
+	if first {
+		return t
+	}
+	if s != "" {
+		node := text(s)
+		last.insertAfter(node)
+		last = node
+	}
+	if t.Literal == "" {
+		t.unlink()
+	}
+	return last
+}
+
+func isEmailLocal(c byte) bool {
+	return isAlnum(string(c)) || c == '.' || c == '-' || c == '_' || c == '+'
+}
+
+func isEmailDomain(c byte) bool {
+	return isAlnum(string(c)) || c == '.' || c == '-' || c == '_'
+}
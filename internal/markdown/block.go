@@ -0,0 +1,735 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const codeIndent = 4
+
+var (
+	reATXHeadingMarker   = regexp.MustCompile(`^#{1,6}(?:[ \t]+|$)`)
+	reATXClosingOnly     = regexp.MustCompile(`^[ \t]*#+[ \t]*$`)
+	reATXClosingSequence = regexp.MustCompile(`[ \t]+#+[ \t]*$`)
+	reCodeFence          = regexp.MustCompile("^(?:`{3,}|~{3,})")
+	reClosingCodeFence   = regexp.MustCompile("^(?:`{3,}|~{3,})[ \t]*$")
+	reSetextHeadingLine  = regexp.MustCompile(`^(?:=+|-+)[ \t]*$`)
+	reThematicBreak      = regexp.MustCompile(`^(?:(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|(?:-[ \t]*){3,})$`)
+	reBulletListMarker   = regexp.MustCompile(`^[*+-]`)
+	reOrderedListMarker  = regexp.MustCompile(`^(\d{1,9})([.)])`)
+	reLineEnding         = regexp.MustCompile(`\r\n|\n|\r`)
+	reTaskMarker         = regexp.MustCompile(`^\[([ xX])\][ \t]`)
+
+	reHTMLBlockOpen = []*regexp.Regexp{
+		nil,
+		regexp.MustCompile(`(?i)^<(?:script|pre|textarea|style)(?:\s|>|$)`),
+		regexp.MustCompile(`^<!--`),
+		regexp.MustCompile(`^<[?]`),
+		regexp.MustCompile(`^<![A-Za-z]`),
+		regexp.MustCompile(`^<!\[CDATA\[`),
+		regexp.MustCompile(`(?i)^</?(?:address|article|aside|base|basefont|blockquote|body|caption|center|col|colgroup|dd|details|dialog|dir|div|dl|dt|fieldset|figcaption|figure|footer|form|frame|frameset|h[123456]|head|header|hr|html|iframe|legend|li|link|main|menu|menuitem|nav|noframes|ol|optgroup|option|p|param|search|section|summary|table|tbody|td|tfoot|th|thead|title|tr|track|ul)(?:\s|/?>|$)`),
+		regexp.MustCompile(`(?i)^(?:` + openTag + `|` + closeTag + `)\s*$`),
+	}
+	reHTMLBlockClose = []*regexp.Regexp{
+		nil,
+		regexp.MustCompile(`(?i)</(?:script|pre|textarea|style)>`),
+		regexp.MustCompile(`-->`),
+		regexp.MustCompile(`\?>`),
+		regexp.MustCompile(`>`),
+		regexp.MustCompile(`\]\]>`),
+	}
+)
+
+// continue results
+const (
+	matched = iota
+	notMatched
+	lineConsumed
+)
+
+// block start results
+const (
+	noStart = iota
+	containerStart
+	leafStart
+)
+
+// Parse builds the document tree for src following CommonMark plus the GFM
+// table, strikethrough, task list and autolink extensions.
+func Parse(src []byte) *Node {
+	return parse(src, true)
+}
+
+func parse(src []byte, gfm bool) *Node {
+	p := &parser{
+		doc:    &Node{Type: Document, open: true},
+		refmap: map[string]reference{},
+		gfm:    gfm,
+	}
+	p.tip = p.doc
+	p.oldtip = p.doc
+	p.lastMatchedContainer = p.doc
+
+	input := strings.ReplaceAll(string(src), "\x00", "�")
+	lines := reLineEnding.Split(input, -1)
+	n := len(lines)
+	if strings.HasSuffix(input, "\n") || strings.HasSuffix(input, "\r") {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		p.incorporateLine(lines[i])
+	}
+	for p.tip != nil {
+		p.finalize(p.tip, n)
+	}
+	if gfm {
+		markTaskItems(p.doc)
+	}
+	p.processInlines()
+	return p.doc
+}
+
+type parser struct {
+	doc                  *Node
+	tip                  *Node
+	oldtip               *Node
+	lastMatchedContainer *Node
+	refmap               map[string]reference
+	gfm                  bool
+
+	line                 string
+	lineNumber           int
+	offset               int
+	column               int
+	nextNonspace         int
+	nextNonspaceColumn   int
+	indent               int
+	indented             bool
+	blank                bool
+	partiallyConsumedTab bool
+	allClosed            bool
+}
+
+type reference struct {
+	destination string
+	title       string
+}
+
+var blockStarts = []func(*parser, *Node) int{
+	tableStart,
+	blockQuoteStart,
+	atxHeadingStart,
+	fencedCodeStart,
+	htmlBlockStart,
+	setextHeadingStart,
+	thematicBreakStart,
+	listItemStart,
+	indentedCodeStart,
+}
+
+func (p *parser) incorporateLine(ln string) {
+	allMatched := true
+	container := p.doc
+	p.oldtip = p.tip
+	p.offset = 0
+	p.column = 0
+	p.blank = false
+	p.partiallyConsumedTab = false
+	p.lineNumber++
+	p.line = ln
+
+	for container.LastChild != nil && container.LastChild.open {
+		container = container.LastChild
+		p.findNextNonspace()
+		switch p.continueBlock(container) {
+		case notMatched:
+			allMatched = false
+		case lineConsumed:
+			return
+		}
+		if !allMatched {
+			container = container.Parent
+			break
+		}
+	}
+
+	p.allClosed = container == p.oldtip
+	p.lastMatchedContainer = container
+
+	matchedLeaf := container.Type != Paragraph && container.Type != Table && acceptsLines(container.Type)
+	for !matchedLeaf {
+		p.findNextNonspace()
+		if !p.indented && !maybeSpecial(ln, p.nextNonspace) {
+			p.advanceNextNonspace()
+			break
+		}
+
+		started := false
+		for _, start := range blockStarts {
+			res := start(p, container)
+			if res == containerStart {
+				container = p.tip
+				started = true
+				break
+			}
+			if res == leafStart {
+				container = p.tip
+				matchedLeaf = true
+				started = true
+				break
+			}
+		}
+		if !started {
+			p.advanceNextNonspace()
+			break
+		}
+	}
+
+	if !p.allClosed && !p.blank && p.tip.Type == Paragraph {
+		// lazy paragraph continuation
+		p.addLine()
+		return
+	}
+
+	p.closeUnmatchedBlocks()
+	if p.blank && container.LastChild != nil {
+		container.LastChild.lastLineBlank = true
+	}
+
+	t := container.Type
+	lastLineBlank := p.blank &&
+		!(t == BlockQuote ||
+			(t == CodeBlock && container.Fenced) ||
+			(t == Item && container.FirstChild == nil && container.startLine == p.lineNumber))
+	for c := container; c != nil; c = c.Parent {
+		c.lastLineBlank = lastLineBlank
+	}
+
+	switch {
+	case acceptsLines(t):
+		p.addLine()
+		if t == HTMLBlock && container.htmlBlockType >= 1 && container.htmlBlockType <= 5 &&
+			reHTMLBlockClose[container.htmlBlockType].MatchString(p.line[p.offset:]) {
+			p.finalize(container, p.lineNumber)
+		}
+	case t == Table:
+		if p.offset < len(ln) && !p.blank {
+			container.rows = append(container.rows, p.line[p.offset:])
+		}
+	case p.offset < len(ln) && !p.blank:
+		p.addChild(Paragraph, p.offset)
+		p.advanceNextNonspace()
+		p.addLine()
+	}
+}
+
+func maybeSpecial(ln string, i int) bool {
+	if i >= len(ln) {
+		return false
+	}
+	return strings.IndexByte("#`~*+_=<>0123456789-|:", ln[i]) >= 0
+}
+
+func acceptsLines(t NodeType) bool {
+	return t == Paragraph || t == CodeBlock || t == HTMLBlock
+}
+
+func canContain(parent, child NodeType) bool {
+	switch parent {
+	case Document, BlockQuote, Item:
+		return child != Item
+	case List:
+		return child == Item
+	}
+	return false
+}
+
+func (p *parser) continueBlock(container *Node) int {
+	ln := p.line
+	switch container.Type {
+	case BlockQuote:
+		if !p.indented && peek(ln, p.nextNonspace) == '>' {
+			p.advanceNextNonspace()
+			p.advanceOffset(1, false)
+			if isSpaceOrTab(peek(ln, p.offset)) {
+				p.advanceOffset(1, true)
+			}
+			return matched
+		}
+		return notMatched
+	case Item:
+		if p.blank {
+			if container.FirstChild == nil {
+				return notMatched
+			}
+			p.advanceNextNonspace()
+		} else if p.indent >= container.List.markerOffset+container.List.padding {
+			p.advanceOffset(container.List.markerOffset+container.List.padding, true)
+		} else {
+			return notMatched
+		}
+		return matched
+	case Heading, ThematicBreak:
+		return notMatched
+	case CodeBlock:
+		if container.Fenced {
+			rest := ln[p.nextNonspace:]
+			if p.indent <= 3 && peek(ln, p.nextNonspace) == container.fenceChar {
+				if m := reClosingCodeFence.FindString(rest); m != "" && fenceRun(m) >= container.fenceLength {
+					p.finalize(container, p.lineNumber)
+					return lineConsumed
+				}
+			}
+			for i := container.fenceOffset; i > 0 && isSpaceOrTab(peek(ln, p.offset)); i-- {
+				p.advanceOffset(1, true)
+			}
+			return matched
+		}
+		if p.indent >= codeIndent {
+			p.advanceOffset(codeIndent, true)
+		} else if p.blank {
+			p.advanceNextNonspace()
+		} else {
+			return notMatched
+		}
+		return matched
+	case HTMLBlock:
+		if p.blank && (container.htmlBlockType == 6 || container.htmlBlockType == 7) {
+			return notMatched
+		}
+		return matched
+	case Paragraph, Table:
+		if p.blank {
+			return notMatched
+		}
+		return matched
+	}
+	return matched
+}
+
+func fenceRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] == s[0] {
+		n++
+	}
+	return n
+}
+
+func (p *parser) finalize(block *Node, lineNumber int) {
+	above := block.Parent
+	block.open = false
+
+	switch block.Type {
+	case Paragraph:
+		hasRefs := false
+		for len(block.content) > 0 && block.content[0] == '[' {
+			n := p.parseReference(string(block.content))
+			if n == 0 {
+				break
+			}
+			block.content = block.content[n:]
+			hasRefs = true
+		}
+		if hasRefs && isBlank(string(block.content)) {
+			block.unlink()
+		}
+	case CodeBlock:
+		content := string(block.content)
+		if block.Fenced {
+			nl := strings.IndexByte(content, '\n')
+			first, rest := content, ""
+			if nl >= 0 {
+				first, rest = content[:nl], content[nl+1:]
+			}
+			block.Info = unescapeString(strings.TrimSpace(first))
+			block.Literal = rest
+		} else {
+			lines := strings.Split(content, "\n")
+			for len(lines) > 0 && isBlank(lines[len(lines)-1]) {
+				lines = lines[:len(lines)-1]
+			}
+			block.Literal = strings.Join(lines, "\n") + "\n"
+		}
+		block.content = nil
+	case HTMLBlock:
+		block.Literal = strings.TrimSuffix(string(block.content), "\n")
+		block.content = nil
+	case List:
+		block.List.Tight = true
+	items:
+		for item := block.FirstChild; item != nil; item = item.Next {
+			if endsWithBlankLine(item) && item.Next != nil {
+				block.List.Tight = false
+				break
+			}
+			for sub := item.FirstChild; sub != nil; sub = sub.Next {
+				if endsWithBlankLine(sub) && (item.Next != nil || sub.Next != nil) {
+					block.List.Tight = false
+					break items
+				}
+			}
+		}
+		for item := block.FirstChild; item != nil; item = item.Next {
+			item.List.Tight = block.List.Tight
+		}
+	case Table:
+		finalizeTable(block)
+	}
+
+	p.tip = above
+}
+
+func endsWithBlankLine(block *Node) bool {
+	for block != nil {
+		if block.lastLineBlank {
+			return true
+		}
+		if !block.lastLineChecked && (block.Type == List || block.Type == Item) {
+			block.lastLineChecked = true
+			block = block.LastChild
+		} else {
+			block.lastLineChecked = true
+			break
+		}
+	}
+	return false
+}
+
+func (p *parser) addChild(t NodeType, offset int) *Node {
+	for !canContain(p.tip.Type, t) {
+		p.finalize(p.tip, p.lineNumber-1)
+	}
+	n := &Node{Type: t, open: true, startLine: p.lineNumber}
+	p.tip.AppendChild(n)
+	p.tip = n
+	return n
+}
+
+func (p *parser) addLine() {
+	if p.partiallyConsumedTab {
+		p.offset++
+		charsToTab := 4 - (p.column % 4)
+		p.tip.content = append(p.tip.content, strings.Repeat(" ", charsToTab)...)
+	}
+	p.tip.content = append(p.tip.content, p.line[p.offset:]...)
+	p.tip.content = append(p.tip.content, '\n')
+}
+
+func (p *parser) closeUnmatchedBlocks() {
+	if p.allClosed {
+		return
+	}
+	for p.oldtip != p.lastMatchedContainer {
+		parent := p.oldtip.Parent
+		p.finalize(p.oldtip, p.lineNumber-1)
+		p.oldtip = parent
+	}
+	p.allClosed = true
+}
+
+func (p *parser) findNextNonspace() {
+	i := p.offset
+	cols := p.column
+	var c byte
+	for i < len(p.line) {
+		c = p.line[i]
+		if c == ' ' {
+			i++
+			cols++
+		} else if c == '\t' {
+			i++
+			cols += 4 - (cols % 4)
+		} else {
+			break
+		}
+	}
+	p.blank = i >= len(p.line)
+	p.nextNonspace = i
+	p.nextNonspaceColumn = cols
+	p.indent = cols - p.column
+	p.indented = p.indent >= codeIndent
+}
+
+func (p *parser) advanceNextNonspace() {
+	p.offset = p.nextNonspace
+	p.column = p.nextNonspaceColumn
+	p.partiallyConsumedTab = false
+}
+
+func (p *parser) advanceOffset(count int, columns bool) {
+	for count > 0 && p.offset < len(p.line) {
+		if p.line[p.offset] == '\t' {
+			charsToTab := 4 - (p.column % 4)
+			if columns {
+				p.partiallyConsumedTab = charsToTab > count
+				advance := min(charsToTab, count)
+				p.column += advance
+				if !p.partiallyConsumedTab {
+					p.offset++
+				}
+				count -= advance
+			} else {
+				p.partiallyConsumedTab = false
+				p.column += charsToTab
+				p.offset++
+				count--
+			}
+		} else {
+			p.partiallyConsumedTab = false
+			p.offset++
+			p.column++
+			count--
+		}
+	}
+}
+
+func blockQuoteStart(p *parser, container *Node) int {
+	if p.indented || peek(p.line, p.nextNonspace) != '>' {
+		return noStart
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(1, false)
+	if isSpaceOrTab(peek(p.line, p.offset)) {
+		p.advanceOffset(1, true)
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(BlockQuote, p.nextNonspace)
+	return containerStart
+}
+
+func atxHeadingStart(p *parser, container *Node) int {
+	if p.indented {
+		return noStart
+	}
+	m := reATXHeadingMarker.FindString(p.line[p.nextNonspace:])
+	if m == "" {
+		return noStart
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(len(m), false)
+	p.closeUnmatchedBlocks()
+	h := p.addChild(Heading, p.nextNonspace)
+	h.Level = len(strings.TrimSpace(m))
+	text := p.line[p.offset:]
+	text = reATXClosingOnly.ReplaceAllString(text, "")
+	text = reATXClosingSequence.ReplaceAllString(text, "")
+	h.content = []byte(text)
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStart
+}
+
+func fencedCodeStart(p *parser, container *Node) int {
+	if p.indented {
+		return noStart
+	}
+	rest := p.line[p.nextNonspace:]
+	m := reCodeFence.FindString(rest)
+	if m == "" {
+		return noStart
+	}
+	if m[0] == '`' && strings.IndexByte(rest[len(m):], '`') >= 0 {
+		return noStart
+	}
+	p.closeUnmatchedBlocks()
+	c := p.addChild(CodeBlock, p.nextNonspace)
+	c.Fenced = true
+	c.fenceLength = len(m)
+	c.fenceChar = m[0]
+	c.fenceOffset = p.indent
+	p.advanceNextNonspace()
+	p.advanceOffset(len(m), false)
+	return leafStart
+}
+
+func htmlBlockStart(p *parser, container *Node) int {
+	if p.indented || peek(p.line, p.nextNonspace) != '<' {
+		return noStart
+	}
+	s := p.line[p.nextNonspace:]
+	for blockType := 1; blockType <= 7; blockType++ {
+		if !reHTMLBlockOpen[blockType].MatchString(s) {
+			continue
+		}
+		if blockType == 7 && (container.Type == Paragraph || (!p.allClosed && !p.blank && p.tip.Type == Paragraph)) {
+			continue
+		}
+		p.closeUnmatchedBlocks()
+		b := p.addChild(HTMLBlock, p.offset)
+		b.htmlBlockType = blockType
+		return leafStart
+	}
+	return noStart
+}
+
+func setextHeadingStart(p *parser, container *Node) int {
+	if p.indented || container.Type != Paragraph {
+		return noStart
+	}
+	m := reSetextHeadingLine.FindString(p.line[p.nextNonspace:])
+	if m == "" {
+		return noStart
+	}
+	p.closeUnmatchedBlocks()
+	for len(container.content) > 0 && container.content[0] == '[' {
+		n := p.parseReference(string(container.content))
+		if n == 0 {
+			break
+		}
+		container.content = container.content[n:]
+	}
+	if len(container.content) == 0 {
+		return noStart
+	}
+	h := &Node{Type: Heading, open: true, startLine: container.startLine, content: container.content}
+	if m[0] == '=' {
+		h.Level = 1
+	} else {
+		h.Level = 2
+	}
+	container.insertAfter(h)
+	container.unlink()
+	p.tip = h
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStart
+}
+
+func thematicBreakStart(p *parser, container *Node) int {
+	if p.indented || !reThematicBreak.MatchString(p.line[p.nextNonspace:]) {
+		return noStart
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(ThematicBreak, p.nextNonspace)
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStart
+}
+
+func listItemStart(p *parser, container *Node) int {
+	if p.indented && container.Type != List {
+		return noStart
+	}
+	data, ok := p.parseListMarker(container)
+	if !ok {
+		return noStart
+	}
+	p.closeUnmatchedBlocks()
+	if p.tip.Type != List || !listsMatch(container.List, data) {
+		list := p.addChild(List, p.nextNonspace)
+		list.List = data
+	}
+	item := p.addChild(Item, p.nextNonspace)
+	item.List = data
+	return containerStart
+}
+
+func indentedCodeStart(p *parser, container *Node) int {
+	if !p.indented || p.tip.Type == Paragraph || p.tip.Type == Table || p.blank {
+		return noStart
+	}
+	p.advanceOffset(codeIndent, true)
+	p.closeUnmatchedBlocks()
+	p.addChild(CodeBlock, p.offset)
+	return leafStart
+}
+
+func (p *parser) parseListMarker(container *Node) (ListData, bool) {
+	rest := p.line[p.nextNonspace:]
+	data := ListData{Tight: true, markerOffset: p.indent}
+	if p.indent >= 4 {
+		return data, false
+	}
+
+	var marker string
+	if m := reBulletListMarker.FindString(rest); m != "" {
+		marker = m
+		data.BulletChar = m[0]
+	} else if m := reOrderedListMarker.FindStringSubmatch(rest); m != nil && (container.Type != Paragraph || m[1] == "1") {
+		marker = m[0]
+		data.Ordered = true
+		data.Start, _ = strconv.Atoi(m[1])
+		data.Delimiter = m[2][0]
+	} else {
+		return data, false
+	}
+
+	next := peek(p.line, p.nextNonspace+len(marker))
+	if !(next == 0 || next == '\t' || next == ' ') {
+		return data, false
+	}
+	if container.Type == Paragraph && isBlank(p.line[p.nextNonspace+len(marker):]) {
+		return data, false
+	}
+
+	p.advanceNextNonspace()
+	p.advanceOffset(len(marker), true)
+	spacesStartCol := p.column
+	spacesStartOffset := p.offset
+	for {
+		p.advanceOffset(1, true)
+		if p.column-spacesStartCol >= 5 || !isSpaceOrTab(peek(p.line, p.offset)) {
+			break
+		}
+	}
+	blankItem := p.offset >= len(p.line)
+	spacesAfterMarker := p.column - spacesStartCol
+	if spacesAfterMarker >= 5 || spacesAfterMarker < 1 || blankItem {
+		data.padding = len(marker) + 1
+		p.column = spacesStartCol
+		p.offset = spacesStartOffset
+		if isSpaceOrTab(peek(p.line, p.offset)) {
+			p.advanceOffset(1, true)
+		}
+	} else {
+		data.padding = len(marker) + spacesAfterMarker
+	}
+	return data, true
+}
+
+func listsMatch(list, item ListData) bool {
+	return list.Ordered == item.Ordered && list.Delimiter == item.Delimiter && list.BulletChar == item.BulletChar
+}
+
+// markTaskItems turns list items whose first paragraph starts with "[ ]" or
+// "[x]" into GFM task items.
+func markTaskItems(doc *Node) {
+	doc.Walk(func(n *Node) bool {
+		if n.Type != Item || n.FirstChild == nil || n.FirstChild.Type != Paragraph {
+			return true
+		}
+		para := n.FirstChild
+		m := reTaskMarker.FindSubmatch(para.content)
+		if m == nil {
+			return true
+		}
+		n.Task = true
+		n.Checked = m[1][0] != ' '
+		para.content = para.content[len(m[0]):]
+		return true
+	})
+}
+
+func (p *parser) processInlines() {
+	p.doc.Walk(func(n *Node) bool {
+		switch n.Type {
+		case Paragraph, Heading, TableCell:
+			parseInlines(n, p.refmap, p.gfm)
+			return false
+		}
+		return true
+	})
+}
+
+func peek(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func isSpaceOrTab(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isBlank(s string) bool {
+	return strings.Trim(s, " \t\n\r\v\f") == ""
+}
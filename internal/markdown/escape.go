@@ -0,0 +1,61 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const escapable = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+var (
+	reEntity              = regexp.MustCompile(`^&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	reEntityOrEscapedChar = regexp.MustCompile(`\\[!"#$%&'()*+,./:;<=>?@\[\\\]^_` + "`" + `{|}~-]|&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	reLabelWhitespace     = regexp.MustCompile(`[ \t\r\n]+`)
+)
+
+func isEscapable(c byte) bool {
+	return strings.IndexByte(escapable, c) >= 0
+}
+
+// decodeEntity decodes a single entity or numeric character reference. It
+// returns the input unchanged when the name is not a known entity.
+func decodeEntity(ent string) string {
+	if strings.HasPrefix(ent, "&#") {
+		digits := ent[2 : len(ent)-1]
+		base := 10
+		if digits[0] == 'x' || digits[0] == 'X' {
+			digits = digits[1:]
+			base = 16
+		}
+		n, err := strconv.ParseInt(digits, base, 32)
+		if err != nil || n == 0 || !utf8.ValidRune(rune(n)) {
+			return "�"
+		}
+		return string(rune(n))
+	}
+	return html.UnescapeString(ent)
+}
+
+func unescapeString(s string) string {
+	if strings.IndexByte(s, '\\') < 0 && strings.IndexByte(s, '&') < 0 {
+		return s
+	}
+	return reEntityOrEscapedChar.ReplaceAllStringFunc(s, func(m string) string {
+		if m[0] == '\\' {
+			return m[1:]
+		}
+		return decodeEntity(m)
+	})
+}
+
+// normalizeLabel implements the case-insensitive, whitespace-collapsing
+// matching of link labels. raw includes the surrounding brackets.
+func normalizeLabel(raw string) string {
+	s := strings.TrimSpace(raw[1 : len(raw)-1])
+	s = reLabelWhitespace.ReplaceAllString(s, " ")
+	s = strings.ToLower(strings.ToUpper(s))
+	return strings.ReplaceAll(s, "ß", "ss")
+}
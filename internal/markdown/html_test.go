@@ -0,0 +1,228 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderHTML renders doc the way the CommonMark reference implementation
+// does, so parse results can be compared with the spec examples.
+func renderHTML(doc *Node) string {
+	r := &htmlRenderer{}
+	r.node(doc)
+	return r.b.String()
+}
+
+type htmlRenderer struct {
+	b       strings.Builder
+	inImage int
+}
+
+func (r *htmlRenderer) cr() {
+	s := r.b.String()
+	if len(s) > 0 && !strings.HasSuffix(s, "\n") {
+		r.b.WriteByte('\n')
+	}
+}
+
+func (r *htmlRenderer) children(n *Node) {
+	for c := n.FirstChild; c != nil; c = c.Next {
+		r.node(c)
+	}
+}
+
+func (r *htmlRenderer) tag(s string) {
+	if r.inImage == 0 {
+		r.b.WriteString(s)
+	}
+}
+
+func (r *htmlRenderer) node(n *Node) {
+	switch n.Type {
+	case Document:
+		r.children(n)
+	case Paragraph:
+		if gp := n.Parent.Parent; gp != nil && gp.Type == List && gp.List.Tight {
+			r.children(n)
+			return
+		}
+		r.cr()
+		r.b.WriteString("<p>")
+		r.children(n)
+		r.b.WriteString("</p>")
+		r.cr()
+	case Heading:
+		r.cr()
+		fmt.Fprintf(&r.b, "<h%d>", n.Level)
+		r.children(n)
+		fmt.Fprintf(&r.b, "</h%d>", n.Level)
+		r.cr()
+	case BlockQuote:
+		r.cr()
+		r.b.WriteString("<blockquote>")
+		r.cr()
+		r.children(n)
+		r.cr()
+		r.b.WriteString("</blockquote>")
+		r.cr()
+	case List:
+		r.cr()
+		tag := "ul"
+		if n.List.Ordered {
+			tag = "ol"
+			if n.List.Start != 1 {
+				fmt.Fprintf(&r.b, "<ol start=\"%d\">", n.List.Start)
+			} else {
+				r.b.WriteString("<ol>")
+			}
+		} else {
+			r.b.WriteString("<ul>")
+		}
+		r.cr()
+		r.children(n)
+		r.cr()
+		fmt.Fprintf(&r.b, "</%s>", tag)
+		r.cr()
+	case Item:
+		r.b.WriteString("<li>")
+		if n.Task {
+			if n.Checked {
+				r.b.WriteString(`<input checked="" disabled="" type="checkbox"> `)
+			} else {
+				r.b.WriteString(`<input disabled="" type="checkbox"> `)
+			}
+		}
+		r.children(n)
+		r.b.WriteString("</li>")
+		r.cr()
+	case ThematicBreak:
+		r.cr()
+		r.b.WriteString("<hr />")
+		r.cr()
+	case CodeBlock:
+		r.cr()
+		r.b.WriteString("<pre><code")
+		if lang := strings.Fields(n.Info); len(lang) > 0 {
+			fmt.Fprintf(&r.b, ` class="language-%s"`, escapeHTML(lang[0]))
+		}
+		r.b.WriteString(">")
+		r.b.WriteString(escapeHTML(n.Literal))
+		r.b.WriteString("</code></pre>")
+		r.cr()
+	case HTMLBlock:
+		r.cr()
+		r.b.WriteString(n.Literal)
+		r.cr()
+	case Table:
+		r.cr()
+		r.b.WriteString("<table>\n")
+		for row := n.FirstChild; row != nil; row = row.Next {
+			if row.Header {
+				r.b.WriteString("<thead>\n")
+			} else if row.Prev != nil && row.Prev.Header {
+				r.b.WriteString("<tbody>\n")
+			}
+			r.node(row)
+			if row.Header {
+				r.b.WriteString("</thead>\n")
+			}
+		}
+		if n.LastChild != nil && !n.LastChild.Header {
+			r.b.WriteString("</tbody>\n")
+		}
+		r.b.WriteString("</table>")
+		r.cr()
+	case TableRow:
+		r.b.WriteString("<tr>\n")
+		tag := "td"
+		if n.Header {
+			tag = "th"
+		}
+		for cell := n.FirstChild; cell != nil; cell = cell.Next {
+			r.b.WriteString("<" + tag)
+			switch cell.Align[0] {
+			case AlignLeft:
+				r.b.WriteString(` align="left"`)
+			case AlignCenter:
+				r.b.WriteString(` align="center"`)
+			case AlignRight:
+				r.b.WriteString(` align="right"`)
+			}
+			r.b.WriteString(">")
+			r.children(cell)
+			r.b.WriteString("</" + tag + ">\n")
+		}
+		r.b.WriteString("</tr>\n")
+	case Text:
+		r.b.WriteString(escapeHTML(n.Literal))
+	case SoftBreak:
+		r.b.WriteString("\n")
+	case HardBreak:
+		r.tag("<br />")
+		r.b.WriteString("\n")
+	case Code:
+		r.tag("<code>")
+		r.b.WriteString(escapeHTML(n.Literal))
+		r.tag("</code>")
+	case Emph:
+		r.tag("<em>")
+		r.children(n)
+		r.tag("</em>")
+	case Strong:
+		r.tag("<strong>")
+		r.children(n)
+		r.tag("</strong>")
+	case Strikethrough:
+		r.tag("<del>")
+		r.children(n)
+		r.tag("</del>")
+	case Link:
+		r.tag(`<a href="` + escapeHTML(normalizeURI(n.Destination)) + `"`)
+		if n.Title != "" {
+			r.tag(` title="` + escapeHTML(n.Title) + `"`)
+		}
+		r.tag(">")
+		r.children(n)
+		r.tag("</a>")
+	case Image:
+		if r.inImage == 0 {
+			r.b.WriteString(`<img src="` + escapeHTML(normalizeURI(n.Destination)) + `" alt="`)
+		}
+		r.inImage++
+		r.children(n)
+		r.inImage--
+		if r.inImage == 0 {
+			if n.Title != "" {
+				r.b.WriteString(`" title="` + escapeHTML(n.Title))
+			}
+			r.b.WriteString(`" />`)
+		}
+	case HTMLInline:
+		r.tag(n.Literal)
+	}
+}
+
+func escapeHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+func normalizeURI(s string) string {
+	const safe = ";/?:@&=+$,-_.!~*'()#"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(c)
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || strings.IndexByte(safe, c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
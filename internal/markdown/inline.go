@@ -0,0 +1,739 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	tagName        = `[A-Za-z][A-Za-z0-9-]*`
+	attributeName  = `[a-zA-Z_:][a-zA-Z0-9:._-]*`
+	attributeValue = `(?:[^"'=<>` + "`" + `\x00-\x20]+|'[^']*'|"[^"]*")`
+	attribute      = `(?:\s+` + attributeName + `(?:\s*=\s*` + attributeValue + `)?)`
+	openTag        = `<` + tagName + attribute + `*\s*/?>`
+	closeTag       = `</` + tagName + `\s*>`
+	htmlComment    = `<!-->|<!--->|<!--[\s\S]*?-->`
+	procInstr      = `<\?[\s\S]*?\?>`
+	declaration    = `<![A-Za-z]+[^>]*>`
+	cdata          = `<!\[CDATA\[[\s\S]*?\]\]>`
+)
+
+var (
+	reHTMLTag       = regexp.MustCompile(`^(?:` + openTag + `|` + closeTag + `|` + htmlComment + `|` + procInstr + `|` + declaration + `|` + cdata + `)`)
+	reEmailAutolink = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	reAutolink      = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9.+-]{1,31}:[^<>\x00-\x20]*)>`)
+	reLinkLabel     = regexp.MustCompile(`^\[(?:[^\\\[\]]|\\[\s\S]){0,1000}\]`)
+	reDestBraces    = regexp.MustCompile(`^<(?:[^<>\n\\\x00]|\\[\s\S])*>`)
+	reSpnl          = regexp.MustCompile(`^[ \t]*(?:\n[ \t]*)?`)
+	reSpaceAtEOL    = regexp.MustCompile(`^[ \t]*(?:\n|$)`)
+)
+
+type delimiter struct {
+	char      byte
+	numdelims int
+	origdelim int
+	node      *Node
+	previous  *delimiter
+	next      *delimiter
+	canOpen   bool
+	canClose  bool
+}
+
+type bracket struct {
+	node          *Node
+	previous      *bracket
+	prevDelimiter *delimiter
+	index         int
+	image         bool
+	active        bool
+	bracketAfter  bool
+}
+
+type inlineParser struct {
+	subject    string
+	pos        int
+	delimiters *delimiter
+	brackets   *bracket
+	refmap     map[string]reference
+	gfm        bool
+}
+
+func parseInlines(block *Node, refmap map[string]reference, gfm bool) {
+	ip := &inlineParser{
+		subject: strings.Trim(string(block.content), " \t\n\r"),
+		refmap:  refmap,
+		gfm:     gfm,
+	}
+	block.content = nil
+	for ip.parseInline(block) {
+	}
+	ip.processEmphasis(nil)
+	mergeText(block)
+	if gfm {
+		linkifyEmails(block)
+	}
+}
+
+func text(s string) *Node {
+	return &Node{Type: Text, Literal: s}
+}
+
+func (ip *inlineParser) peek() byte {
+	if ip.pos < len(ip.subject) {
+		return ip.subject[ip.pos]
+	}
+	return 0
+}
+
+func (ip *inlineParser) eof() bool {
+	return ip.pos >= len(ip.subject)
+}
+
+// match consumes re at the current position and returns the matched text.
+func (ip *inlineParser) match(re *regexp.Regexp) (string, bool) {
+	loc := re.FindStringIndex(ip.subject[ip.pos:])
+	if loc == nil {
+		return "", false
+	}
+	m := ip.subject[ip.pos+loc[0] : ip.pos+loc[1]]
+	ip.pos += loc[1]
+	return m, true
+}
+
+func (ip *inlineParser) parseInline(block *Node) bool {
+	if ip.eof() {
+		return false
+	}
+	c := ip.peek()
+	res := false
+	switch c {
+	case '\n':
+		res = ip.parseNewline(block)
+	case '\\':
+		res = ip.parseBackslash(block)
+	case '`':
+		res = ip.parseBackticks(block)
+	case '*', '_':
+		res = ip.handleDelim(c, block)
+	case '~':
+		res = ip.gfm && ip.handleDelim(c, block)
+	case '[':
+		res = ip.parseOpenBracket(block)
+	case '!':
+		res = ip.parseBang(block)
+	case ']':
+		res = ip.parseCloseBracket(block)
+	case '<':
+		res = ip.parseAutolink(block) || ip.parseHTMLTag(block)
+	case '&':
+		res = ip.parseEntity(block)
+	default:
+		res = ip.parseExtendedAutolink(block) || ip.parseString(block)
+	}
+	if !res {
+		_, size := utf8.DecodeRuneInString(ip.subject[ip.pos:])
+		block.AppendChild(text(ip.subject[ip.pos : ip.pos+size]))
+		ip.pos += size
+	}
+	return true
+}
+
+func (ip *inlineParser) isSpecial(c byte) bool {
+	return strings.IndexByte("\n`[]\\!<&*_", c) >= 0 || (ip.gfm && c == '~')
+}
+
+func (ip *inlineParser) parseString(block *Node) bool {
+	start := ip.pos
+	for ip.pos < len(ip.subject) && !ip.isSpecial(ip.subject[ip.pos]) {
+		if ip.gfm && ip.pos > start && autolinkCandidate(ip.subject, ip.pos) {
+			break
+		}
+		ip.pos++
+	}
+	if ip.pos == start {
+		return false
+	}
+	block.AppendChild(text(ip.subject[start:ip.pos]))
+	return true
+}
+
+func (ip *inlineParser) parseNewline(block *Node) bool {
+	ip.pos++
+	last := block.LastChild
+	if last != nil && last.Type == Text && strings.HasSuffix(last.Literal, " ") {
+		hard := strings.HasSuffix(last.Literal, "  ")
+		last.Literal = strings.TrimRight(last.Literal, " ")
+		if hard {
+			block.AppendChild(&Node{Type: HardBreak})
+		} else {
+			block.AppendChild(&Node{Type: SoftBreak})
+		}
+	} else {
+		block.AppendChild(&Node{Type: SoftBreak})
+	}
+	for ip.peek() == ' ' {
+		ip.pos++
+	}
+	return true
+}
+
+func (ip *inlineParser) parseBackslash(block *Node) bool {
+	ip.pos++
+	switch {
+	case ip.peek() == '\n':
+		ip.pos++
+		block.AppendChild(&Node{Type: HardBreak})
+	case !ip.eof() && isEscapable(ip.peek()):
+		block.AppendChild(text(ip.subject[ip.pos : ip.pos+1]))
+		ip.pos++
+	default:
+		block.AppendChild(text(`\`))
+	}
+	return true
+}
+
+func (ip *inlineParser) parseBackticks(block *Node) bool {
+	start := ip.pos
+	for ip.peek() == '`' {
+		ip.pos++
+	}
+	ticks := ip.subject[start:ip.pos]
+	afterOpen := ip.pos
+
+	for ip.pos < len(ip.subject) {
+		i := strings.IndexByte(ip.subject[ip.pos:], '`')
+		if i < 0 {
+			break
+		}
+		runStart := ip.pos + i
+		ip.pos = runStart
+		for ip.peek() == '`' {
+			ip.pos++
+		}
+		if ip.pos-runStart == len(ticks) {
+			contents := strings.ReplaceAll(ip.subject[afterOpen:runStart], "\n", " ")
+			if len(contents) > 2 && contents[0] == ' ' && contents[len(contents)-1] == ' ' && strings.Trim(contents, " ") != "" {
+				contents = contents[1 : len(contents)-1]
+			}
+			block.AppendChild(&Node{Type: Code, Literal: contents})
+			return true
+		}
+	}
+	ip.pos = afterOpen
+	block.AppendChild(text(ticks))
+	return true
+}
+
+func (ip *inlineParser) scanDelims(c byte) (n int, canOpen, canClose bool) {
+	start := ip.pos
+	for ip.pos < len(ip.subject) && ip.subject[ip.pos] == c {
+		n++
+		ip.pos++
+	}
+	defer func() { ip.pos = start }()
+
+	before := '\n'
+	if start > 0 {
+		before, _ = utf8.DecodeLastRuneInString(ip.subject[:start])
+	}
+	after := '\n'
+	if ip.pos < len(ip.subject) {
+		after, _ = utf8.DecodeRuneInString(ip.subject[ip.pos:])
+	}
+
+	afterSpace := isUnicodeSpace(after)
+	afterPunct := isUnicodePunct(after)
+	beforeSpace := isUnicodeSpace(before)
+	beforePunct := isUnicodePunct(before)
+
+	leftFlanking := !afterSpace && (!afterPunct || beforeSpace || beforePunct)
+	rightFlanking := !beforeSpace && (!beforePunct || afterSpace || afterPunct)
+	if c == '_' {
+		canOpen = leftFlanking && (!rightFlanking || beforePunct)
+		canClose = rightFlanking && (!leftFlanking || afterPunct)
+	} else {
+		canOpen = leftFlanking
+		canClose = rightFlanking
+	}
+	return n, canOpen, canClose
+}
+
+func isUnicodeSpace(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\f' || r == '\r' || unicode.Is(unicode.Zs, r)
+}
+
+func isUnicodePunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func (ip *inlineParser) handleDelim(c byte, block *Node) bool {
+	n, canOpen, canClose := ip.scanDelims(c)
+	if n == 0 {
+		return false
+	}
+	node := text(ip.subject[ip.pos : ip.pos+n])
+	ip.pos += n
+	block.AppendChild(node)
+	if c == '~' && n > 2 {
+		return true
+	}
+	if canOpen || canClose {
+		d := &delimiter{
+			char:      c,
+			numdelims: n,
+			origdelim: n,
+			node:      node,
+			previous:  ip.delimiters,
+			canOpen:   canOpen,
+			canClose:  canClose,
+		}
+		if d.previous != nil {
+			d.previous.next = d
+		}
+		ip.delimiters = d
+	}
+	return true
+}
+
+func (ip *inlineParser) removeDelimiter(d *delimiter) {
+	if d.previous != nil {
+		d.previous.next = d.next
+	}
+	if d.next == nil {
+		ip.delimiters = d.previous
+	} else {
+		d.next.previous = d.previous
+	}
+}
+
+func openersBottomIndex(d *delimiter) int {
+	switch d.char {
+	case '~':
+		return 12 + min(d.origdelim, 2) - 1
+	case '_':
+		idx := d.origdelim % 3
+		if d.canOpen {
+			idx += 3
+		}
+		return idx
+	default:
+		idx := 6 + d.origdelim%3
+		if d.canOpen {
+			idx += 3
+		}
+		return idx
+	}
+}
+
+func (ip *inlineParser) processEmphasis(stackBottom *delimiter) {
+	var openersBottom [14]*delimiter
+	for i := range openersBottom {
+		openersBottom[i] = stackBottom
+	}
+
+	closer := ip.delimiters
+	for closer != nil && closer.previous != stackBottom {
+		closer = closer.previous
+	}
+
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+			continue
+		}
+		idx := openersBottomIndex(closer)
+		opener := closer.previous
+		found := false
+		for opener != nil && opener != stackBottom && opener != openersBottom[idx] {
+			if opener.char == closer.char && opener.canOpen {
+				if closer.char == '~' {
+					if opener.numdelims == closer.numdelims {
+						found = true
+						break
+					}
+				} else {
+					oddMatch := (closer.canOpen || opener.canClose) && closer.origdelim%3 != 0 &&
+						(opener.origdelim+closer.origdelim)%3 == 0
+					if !oddMatch {
+						found = true
+						break
+					}
+				}
+			}
+			opener = opener.previous
+		}
+		oldCloser := closer
+
+		if found {
+			use := 1
+			nodeType := Emph
+			switch {
+			case closer.char == '~':
+				use = closer.numdelims
+				nodeType = Strikethrough
+			case closer.numdelims >= 2 && opener.numdelims >= 2:
+				use = 2
+				nodeType = Strong
+			}
+			openerInl := opener.node
+			closerInl := closer.node
+			opener.numdelims -= use
+			closer.numdelims -= use
+			openerInl.Literal = openerInl.Literal[:len(openerInl.Literal)-use]
+			closerInl.Literal = closerInl.Literal[:len(closerInl.Literal)-use]
+
+			emph := &Node{Type: nodeType}
+			for tmp := openerInl.Next; tmp != nil && tmp != closerInl; {
+				next := tmp.Next
+				emph.AppendChild(tmp)
+				tmp = next
+			}
+			openerInl.insertAfter(emph)
+
+			if opener.next != closer {
+				opener.next = closer
+				closer.previous = opener
+			}
+			if opener.numdelims == 0 {
+				openerInl.unlink()
+				ip.removeDelimiter(opener)
+			}
+			if closer.numdelims == 0 {
+				closerInl.unlink()
+				next := closer.next
+				ip.removeDelimiter(closer)
+				closer = next
+			}
+		} else {
+			closer = closer.next
+			openersBottom[idx] = oldCloser.previous
+			if !oldCloser.canOpen {
+				ip.removeDelimiter(oldCloser)
+			}
+		}
+	}
+
+	for ip.delimiters != nil && ip.delimiters != stackBottom {
+		ip.removeDelimiter(ip.delimiters)
+	}
+}
+
+func (ip *inlineParser) addBracket(node *Node, index int, image bool) {
+	if ip.brackets != nil {
+		ip.brackets.bracketAfter = true
+	}
+	ip.brackets = &bracket{
+		node:          node,
+		previous:      ip.brackets,
+		prevDelimiter: ip.delimiters,
+		index:         index,
+		image:         image,
+		active:        true,
+	}
+}
+
+func (ip *inlineParser) parseOpenBracket(block *Node) bool {
+	start := ip.pos
+	ip.pos++
+	node := text("[")
+	block.AppendChild(node)
+	ip.addBracket(node, start, false)
+	return true
+}
+
+func (ip *inlineParser) parseBang(block *Node) bool {
+	start := ip.pos
+	ip.pos++
+	if ip.peek() == '[' {
+		ip.pos++
+		node := text("![")
+		block.AppendChild(node)
+		ip.addBracket(node, start+1, true)
+	} else {
+		block.AppendChild(text("!"))
+	}
+	return true
+}
+
+func (ip *inlineParser) parseCloseBracket(block *Node) bool {
+	ip.pos++
+	start := ip.pos
+
+	opener := ip.brackets
+	if opener == nil {
+		block.AppendChild(text("]"))
+		return true
+	}
+	if !opener.active {
+		block.AppendChild(text("]"))
+		ip.brackets = opener.previous
+		return true
+	}
+
+	var dest, title string
+	found := false
+	savepos := ip.pos
+
+	if ip.peek() == '(' {
+		ip.pos++
+		ip.match(reSpnl)
+		if d, ok := ip.parseLinkDestination(); ok {
+			dest = d
+			ip.match(reSpnl)
+			if ip.pos > 0 && isUnicodeSpace(rune(ip.subject[ip.pos-1])) {
+				if t, ok := ip.parseLinkTitle(); ok {
+					title = t
+				}
+			}
+			ip.match(reSpnl)
+			if ip.peek() == ')' {
+				ip.pos++
+				found = true
+			}
+		}
+		if !found {
+			ip.pos = savepos
+		}
+	}
+
+	if !found {
+		beforeLabel := ip.pos
+		n := ip.parseLinkLabel()
+		var label string
+		if n > 2 {
+			label = ip.subject[beforeLabel : beforeLabel+n]
+		} else if !opener.bracketAfter {
+			label = ip.subject[opener.index:start]
+		}
+		if n == 0 {
+			ip.pos = savepos
+		}
+		if label != "" {
+			if ref, ok := ip.refmap[normalizeLabel(label)]; ok {
+				dest = ref.destination
+				title = ref.title
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		ip.brackets = opener.previous
+		ip.pos = start
+		block.AppendChild(text("]"))
+		return true
+	}
+
+	node := &Node{Type: Link, Destination: dest, Title: title}
+	if opener.image {
+		node.Type = Image
+	}
+	for tmp := opener.node.Next; tmp != nil; {
+		next := tmp.Next
+		node.AppendChild(tmp)
+		tmp = next
+	}
+	block.AppendChild(node)
+	ip.processEmphasis(opener.prevDelimiter)
+	ip.brackets = opener.previous
+	opener.node.unlink()
+
+	if !opener.image {
+		for b := ip.brackets; b != nil; b = b.previous {
+			if !b.image {
+				b.active = false
+			}
+		}
+	}
+	return true
+}
+
+func (ip *inlineParser) parseLinkLabel() int {
+	m, ok := ip.match(reLinkLabel)
+	if !ok || len(m) > 1001 {
+		return 0
+	}
+	return len(m)
+}
+
+func (ip *inlineParser) parseLinkDestination() (string, bool) {
+	if m, ok := ip.match(reDestBraces); ok {
+		return unescapeString(m[1 : len(m)-1]), true
+	}
+	if ip.peek() == '<' {
+		return "", false
+	}
+	start := ip.pos
+	parens := 0
+	for ip.pos < len(ip.subject) {
+		c := ip.subject[ip.pos]
+		if c == '\\' && ip.pos+1 < len(ip.subject) && isEscapable(ip.subject[ip.pos+1]) {
+			ip.pos += 2
+		} else if c == '(' {
+			ip.pos++
+			parens++
+		} else if c == ')' {
+			if parens < 1 {
+				break
+			}
+			ip.pos++
+			parens--
+		} else if c <= ' ' || c == 0x7f {
+			break
+		} else {
+			ip.pos++
+		}
+	}
+	if ip.pos == start && ip.peek() != ')' {
+		return "", false
+	}
+	if parens != 0 {
+		return "", false
+	}
+	return unescapeString(ip.subject[start:ip.pos]), true
+}
+
+func (ip *inlineParser) parseLinkTitle() (string, bool) {
+	if ip.eof() {
+		return "", false
+	}
+	open := ip.peek()
+	var closing byte
+	switch open {
+	case '"', '\'':
+		closing = open
+	case '(':
+		closing = ')'
+	default:
+		return "", false
+	}
+	for i := ip.pos + 1; i < len(ip.subject); i++ {
+		c := ip.subject[i]
+		switch {
+		case c == '\\' && i+1 < len(ip.subject):
+			i++
+		case c == closing:
+			title := ip.subject[ip.pos+1 : i]
+			ip.pos = i + 1
+			return unescapeString(title), true
+		case open == '(' && c == '(':
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func (ip *inlineParser) parseAutolink(block *Node) bool {
+	if m, ok := ip.match(reEmailAutolink); ok {
+		addr := m[1 : len(m)-1]
+		link := &Node{Type: Link, Destination: "mailto:" + addr}
+		link.AppendChild(text(addr))
+		block.AppendChild(link)
+		return true
+	}
+	if m, ok := ip.match(reAutolink); ok {
+		uri := m[1 : len(m)-1]
+		link := &Node{Type: Link, Destination: uri}
+		link.AppendChild(text(uri))
+		block.AppendChild(link)
+		return true
+	}
+	return false
+}
+
+func (ip *inlineParser) parseHTMLTag(block *Node) bool {
+	m, ok := ip.match(reHTMLTag)
+	if !ok {
+		return false
+	}
+	block.AppendChild(&Node{Type: HTMLInline, Literal: m})
+	return true
+}
+
+func (ip *inlineParser) parseEntity(block *Node) bool {
+	m, ok := ip.match(reEntity)
+	if !ok {
+		return false
+	}
+	block.AppendChild(text(decodeEntity(m)))
+	return true
+}
+
+// parseReference parses a link reference definition at the start of s and
+// records it. It returns the number of bytes consumed, or 0.
+func (p *parser) parseReference(s string) int {
+	ip := &inlineParser{subject: s, refmap: p.refmap}
+
+	n := ip.parseLinkLabel()
+	if n == 0 {
+		return 0
+	}
+	rawLabel := s[:n]
+	if ip.peek() != ':' {
+		return 0
+	}
+	ip.pos++
+	ip.match(reSpnl)
+
+	dest, ok := ip.parseLinkDestination()
+	if !ok {
+		return 0
+	}
+
+	beforeTitle := ip.pos
+	ip.match(reSpnl)
+	title := ""
+	hasTitle := false
+	if ip.pos != beforeTitle {
+		title, hasTitle = ip.parseLinkTitle()
+	}
+	if !hasTitle {
+		title = ""
+		ip.pos = beforeTitle
+	}
+
+	if _, ok := ip.match(reSpaceAtEOL); !ok {
+		if !hasTitle {
+			return 0
+		}
+		title = ""
+		ip.pos = beforeTitle
+		if _, ok := ip.match(reSpaceAtEOL); !ok {
+			return 0
+		}
+	}
+
+	label := normalizeLabel(rawLabel)
+	if label == "" {
+		return 0
+	}
+	if _, exists := p.refmap[label]; !exists {
+		p.refmap[label] = reference{destination: dest, title: title}
+	}
+	return ip.pos
+}
+
+// mergeText joins adjacent Text nodes left behind by unmatched delimiters
+// and brackets, and drops the ones that ended up empty.
+func mergeText(n *Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.Next
+		if c.Type != Text {
+			mergeText(c)
+			c = next
+			continue
+		}
+		for next != nil && next.Type == Text {
+			c.Literal += next.Literal
+			after := next.Next
+			next.unlink()
+			next = after
+		}
+		if c.Literal == "" {
+			c.unlink()
+		}
+		c = next
+	}
+}
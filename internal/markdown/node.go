@@ -0,0 +1,153 @@
+package markdown
+
+type NodeType int
+
+const (
+	Document NodeType = iota
+	BlockQuote
+	List
+	Item
+	Paragraph
+	Heading
+	ThematicBreak
+	CodeBlock
+	HTMLBlock
+	Table
+	TableRow
+	TableCell
+
+	Text
+	SoftBreak
+	HardBreak
+	Code
+	Emph
+	Strong
+	Strikethrough
+	Link
+	Image
+	HTMLInline
+)
+
+type Alignment int
+
+const (
+	AlignNone Alignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+type ListData struct {
+	Ordered    bool
+	BulletChar byte
+	Start      int
+	Delimiter  byte
+	Tight      bool
+
+	padding      int
+	markerOffset int
+}
+
+// Node is an element of the document tree. Which fields are meaningful
+// depends on Type; children are kept as a doubly linked list.
+type Node struct {
+	Type       NodeType
+	Parent     *Node
+	FirstChild *Node
+	LastChild  *Node
+	Prev       *Node
+	Next       *Node
+
+	// Literal holds the text of Text, Code, HTMLInline, CodeBlock and
+	// HTMLBlock nodes.
+	Literal string
+	// Level is the heading level, 1 to 6.
+	Level int
+	// Info is the info string of a fenced code block.
+	Info   string
+	Fenced bool
+	// List is set on List and Item nodes.
+	List ListData
+	// Task and Checked describe GFM task list items.
+	Task    bool
+	Checked bool
+	// Destination and Title are set on Link and Image nodes.
+	Destination string
+	Title       string
+	// Align holds the column alignments of a Table and the alignment of a
+	// TableCell (first element).
+	Align []Alignment
+	// Header marks the header row of a table.
+	Header bool
+
+	open            bool
+	content         []byte
+	startLine       int
+	lastLineBlank   bool
+	lastLineChecked bool
+	fenceChar       byte
+	fenceLength     int
+	fenceOffset     int
+	htmlBlockType   int
+	rows            []string
+}
+
+func (n *Node) IsBlock() bool {
+	return n.Type < Text
+}
+
+func (n *Node) AppendChild(child *Node) {
+	child.unlink()
+	child.Parent = n
+	if n.LastChild != nil {
+		n.LastChild.Next = child
+		child.Prev = n.LastChild
+		n.LastChild = child
+	} else {
+		n.FirstChild = child
+		n.LastChild = child
+	}
+}
+
+func (n *Node) insertAfter(sibling *Node) {
+	sibling.unlink()
+	sibling.Next = n.Next
+	if sibling.Next != nil {
+		sibling.Next.Prev = sibling
+	}
+	sibling.Prev = n
+	n.Next = sibling
+	sibling.Parent = n.Parent
+	if sibling.Next == nil && sibling.Parent != nil {
+		sibling.Parent.LastChild = sibling
+	}
+}
+
+func (n *Node) unlink() {
+	if n.Prev != nil {
+		n.Prev.Next = n.Next
+	} else if n.Parent != nil {
+		n.Parent.FirstChild = n.Next
+	}
+	if n.Next != nil {
+		n.Next.Prev = n.Prev
+	} else if n.Parent != nil {
+		n.Parent.LastChild = n.Prev
+	}
+	n.Parent = nil
+	n.Next = nil
+	n.Prev = nil
+}
+
+// Walk calls fn for n and every descendant in document order. Children are
+// skipped when fn returns false.
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.Next
+		c.Walk(fn)
+		c = next
+	}
+}
@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+type specExample struct {
+	Markdown string `json:"markdown"`
+	HTML     string `json:"html"`
+	Example  int    `json:"example"`
+	Section  string `json:"section"`
+}
+
+func loadExamples(t *testing.T, path string) []specExample {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	var examples []specExample
+	if err := json.Unmarshal(data, &examples); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return examples
+}
+
+func runExamples(t *testing.T, path string, gfm bool) {
+	for _, ex := range loadExamples(t, path) {
+		t.Run(fmt.Sprintf("%d", ex.Example), func(t *testing.T) {
+			got := renderHTML(parse([]byte(ex.Markdown), gfm))
+			if got != ex.HTML {
+				t.Errorf("%s example %d\nmarkdown: %q\n     got: %q\n    want: %q", ex.Section, ex.Example, ex.Markdown, got, ex.HTML)
+			}
+		})
+	}
+}
+
+// TestCommonMarkSpec runs the examples of CommonMark spec 0.31.2.
+func TestCommonMarkSpec(t *testing.T) {
+	runExamples(t, "testdata/spec.json", false)
+}
+
+// TestGFMExtensions runs the table, task list, strikethrough and autolink
+// examples of the GitHub Flavored Markdown spec.
+func TestGFMExtensions(t *testing.T) {
+	runExamples(t, "testdata/gfm.json", true)
+}
@@ -0,0 +1,106 @@
+package markdown
+
+import "strings"
+
+// tableStart recognizes a GFM delimiter row below a paragraph line that has
+// the same number of cells. That line becomes the table header; any lines
+// above it stay in the paragraph.
+func tableStart(p *parser, container *Node) int {
+	if !p.gfm || p.indented || container.Type != Paragraph {
+		return noStart
+	}
+	delim := p.line[p.nextNonspace:]
+	aligns, ok := parseDelimiterRow(delim)
+	if !ok {
+		return noStart
+	}
+	lines := strings.Split(strings.TrimSuffix(string(container.content), "\n"), "\n")
+	header := lines[len(lines)-1]
+	if len(splitTableRow(header)) != len(aligns) {
+		return noStart
+	}
+
+	p.closeUnmatchedBlocks()
+	table := &Node{Type: Table, open: true, startLine: p.lineNumber - 1, Align: aligns, rows: []string{header}}
+	container.insertAfter(table)
+	if len(lines) == 1 {
+		container.unlink()
+	} else {
+		container.content = []byte(strings.Join(lines[:len(lines)-1], "\n") + "\n")
+		p.finalize(container, p.lineNumber-1)
+	}
+	p.tip = table
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStart
+}
+
+func parseDelimiterRow(line string) ([]Alignment, bool) {
+	if strings.IndexByte(line, '|') < 0 {
+		return nil, false
+	}
+	cells := splitTableRow(line)
+	if len(cells) == 0 {
+		return nil, false
+	}
+	aligns := make([]Alignment, len(cells))
+	for i, cell := range cells {
+		left := strings.HasPrefix(cell, ":")
+		right := strings.HasSuffix(cell, ":")
+		dashes := strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if dashes == "" || strings.Trim(dashes, "-") != "" {
+			return nil, false
+		}
+		switch {
+		case left && right:
+			aligns[i] = AlignCenter
+		case left:
+			aligns[i] = AlignLeft
+		case right:
+			aligns[i] = AlignRight
+		}
+	}
+	return aligns, true
+}
+
+// splitTableRow splits a row on unescaped pipes, dropping the optional
+// leading and trailing pipe, and trims every cell.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	cells = append(cells, strings.TrimSpace(line[start:]))
+	if len(cells) == 1 && cells[0] == "" {
+		return nil
+	}
+	return cells
+}
+
+func finalizeTable(table *Node) {
+	for i, raw := range table.rows {
+		row := &Node{Type: TableRow, Header: i == 0}
+		cells := splitTableRow(raw)
+		for col, align := range table.Align {
+			cell := &Node{Type: TableCell, Align: []Alignment{align}}
+			if col < len(cells) {
+				cell.content = []byte(strings.ReplaceAll(cells[col], `\|`, "|"))
+			}
+			row.AppendChild(cell)
+		}
+		table.AppendChild(row)
+	}
+	table.rows = nil
+}
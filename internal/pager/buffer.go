@@ -0,0 +1,514 @@
+package pager
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rodrwan/prettycat/internal/render"
+)
+
+// blockCacheSize is how many rendered blocks of seekable streams are kept.
+const blockCacheSize = 8
+
+// buffer holds the lines of the documents shown by the pager. Streamed
+// documents are rendered block by block as the view reaches them. Blocks
+// of seekable sources are not kept once rendered: only their position is,
+// and they are rendered again when they come back into view.
+type buffer struct {
+	docs  []*docLines
+	src   []render.Doc
+	cache []cachedBlock
+}
+
+type docLines struct {
+	title    string
+	start    int // first buffer line, valid once the documents before are complete
+	head     []string
+	numbers  []int // gutter number per head line, nil when unnumbered
+	numbered bool
+	sticky   [2]int // pinned [first, last) lines, relative to the document
+	stream   *render.Stream
+	blocks   []*docBlock
+	footer   []string
+	done     bool
+}
+
+type docBlock struct {
+	render.Block
+	first int // first line of the block, relative to the document
+	count int
+	last  int // last gutter number in the block, Before when it has none
+	lines []string
+}
+
+type cachedBlock struct {
+	block   *docBlock
+	lines   []string
+	numbers []int
+}
+
+func newBuffer(docs []render.Doc) *buffer {
+	b := &buffer{src: docs}
+	for _, doc := range docs {
+		b.docs = append(b.docs, newDocLines(doc))
+	}
+	b.layout()
+	return b
+}
+
+func newDocLines(doc render.Doc) *docLines {
+	d := &docLines{
+		title:   doc.Title,
+		head:    splitLines(doc.Body),
+		numbers: doc.Numbers,
+		sticky:  [2]int{doc.StickyAt, doc.StickyAt + doc.Sticky},
+		stream:  doc.Stream,
+		footer:  splitLines(doc.Footer),
+		done:    doc.Stream == nil,
+	}
+	d.numbered = doc.Stream != nil && doc.Numbers != nil
+	for _, n := range doc.Numbers {
+		d.numbered = d.numbered || n > 0
+	}
+	return d
+}
+
+// reflow renders the documents again at width. Streamed documents keep the
+// lines they were rendered with.
+func (b *buffer) reflow(width int) {
+	defer b.layout()
+	for i, d := range b.docs {
+		if d.stream != nil {
+			continue
+		}
+		doc, err := b.src[i].Reflow(width)
+		if err != nil {
+			continue
+		}
+		b.src[i] = doc
+		b.docs[i] = newDocLines(doc)
+	}
+}
+
+// anchor locates a line across a reflow: by gutter number in numbered
+// documents, and by its relative position in the others.
+type anchor struct {
+	doc    int
+	number int
+	rel    int
+	len    int
+}
+
+func (b *buffer) anchorAt(line int) anchor {
+	for i, d := range b.docs {
+		if line < d.start+d.len() || !d.done || i == len(b.docs)-1 {
+			a := anchor{doc: i, rel: line - d.start, len: d.len()}
+			if d.numbered {
+				for j := line; j >= d.start && a.number == 0; j-- {
+					a.number = b.number(j)
+				}
+			}
+			return a
+		}
+	}
+	return anchor{}
+}
+
+// lineAnchored returns the line at a after a reflow.
+func (b *buffer) lineAnchored(a anchor) int {
+	if a.doc >= len(b.docs) {
+		return 0
+	}
+	d := b.docs[a.doc]
+	switch {
+	case d.stream != nil || a.len == 0:
+		return d.start + a.rel
+	case a.number > 0:
+		for i, n := range d.numbers {
+			if n >= a.number {
+				return d.start + i
+			}
+		}
+	}
+	return d.start + a.rel*d.len()/a.len
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// len is the number of lines of the document loaded so far.
+func (d *docLines) len() int {
+	n := len(d.head)
+	if k := len(d.blocks); k > 0 {
+		n += d.blocks[k-1].first + d.blocks[k-1].count - len(d.head)
+	}
+	if d.done {
+		n += len(d.footer)
+	}
+	return n
+}
+
+// layout updates the start of every document after a load.
+func (b *buffer) layout() {
+	start := 0
+	for _, d := range b.docs {
+		d.start = start
+		if !d.done {
+			return
+		}
+		start += d.len()
+	}
+}
+
+// Len is the number of lines known so far.
+func (b *buffer) Len() int {
+	n := 0
+	for _, d := range b.docs {
+		n += d.len()
+		if !d.done {
+			break
+		}
+	}
+	return n
+}
+
+// complete reports whether every document has been read to the end.
+func (b *buffer) complete() bool {
+	for _, d := range b.docs {
+		if !d.done {
+			return false
+		}
+	}
+	return true
+}
+
+// load reads blocks until at least n lines are known or there is nothing
+// left to read.
+func (b *buffer) load(n int) {
+	for b.Len() < n {
+		d := b.pending()
+		if d == nil {
+			return
+		}
+		b.loadBlock(d)
+	}
+}
+
+func (b *buffer) loadAll() {
+	for d := b.pending(); d != nil; d = b.pending() {
+		b.loadBlock(d)
+	}
+}
+
+// pending returns the first document not read to the end, unless its
+// reads may wait: its blocks arrive through appendBlock instead.
+func (b *buffer) pending() *docLines {
+	for _, d := range b.docs {
+		if !d.done {
+			if d.waits() {
+				return nil
+			}
+			return d
+		}
+	}
+	return nil
+}
+
+func (d *docLines) following() bool {
+	return !d.done && d.stream.Following()
+}
+
+// waits reports whether reading d may block, on a pipe or a followed file,
+// so that it has to be read in the background.
+func (d *docLines) waits() bool {
+	return !d.done && d.stream.Waits()
+}
+
+// waiting returns the document to read in the background.
+func (b *buffer) waiting() *docLines {
+	for _, d := range b.docs {
+		if d.waits() {
+			return d
+		}
+	}
+	return nil
+}
+
+// followed returns the document whose appended data the pager waits for.
+func (b *buffer) followed() *docLines {
+	for _, d := range b.docs {
+		if d.following() {
+			return d
+		}
+	}
+	return nil
+}
+
+func (b *buffer) loadBlock(d *docLines) {
+	blk, err := d.stream.Next()
+	b.appendBlock(d, blk, err)
+}
+
+// appendBlock adds a block read from the stream of d, and marks d done
+// when err ends the stream.
+func (b *buffer) appendBlock(d *docLines, blk render.Block, err error) {
+	defer b.layout()
+	if err != nil {
+		d.done = true
+		d.stream.Close()
+		if err != io.EOF {
+			d.footer = append([]string{fmt.Sprintf("prettycat: %v", err)}, d.footer...)
+		}
+		if blk.Size == 0 {
+			return
+		}
+	}
+	lines, numbers := splitLines(blk.Body), blk.Numbers
+	db := &docBlock{Block: blk, first: d.len(), count: len(lines), last: blk.Before}
+	if d.done {
+		db.first -= len(d.footer)
+	}
+	for _, n := range numbers {
+		db.last = max(db.last, n)
+	}
+	db.Body, db.Numbers = "", nil
+	if d.stream.Seekable() {
+		b.remember(db, lines, numbers)
+	} else {
+		db.lines, db.Numbers = lines, numbers
+	}
+	d.blocks = append(d.blocks, db)
+}
+
+func (b *buffer) remember(blk *docBlock, lines []string, numbers []int) {
+	if len(b.cache) == blockCacheSize {
+		b.cache = b.cache[1:]
+	}
+	b.cache = append(b.cache, cachedBlock{block: blk, lines: lines, numbers: numbers})
+}
+
+// blockLines returns the rendered lines and gutter numbers of blk.
+func (b *buffer) blockLines(d *docLines, blk *docBlock) ([]string, []int) {
+	if blk.lines != nil {
+		return blk.lines, blk.Numbers
+	}
+	for _, c := range b.cache {
+		if c.block == blk {
+			return c.lines, c.numbers
+		}
+	}
+	body, numbers, err := d.stream.Rerender(blk.Block)
+	lines := splitLines(body)
+	if err != nil {
+		lines = []string{fmt.Sprintf("prettycat: %v", err)}
+	}
+	for len(lines) < blk.count {
+		lines = append(lines, "")
+	}
+	b.remember(blk, lines, numbers)
+	return lines, numbers
+}
+
+// lineAt returns the text and gutter number of line i, which must be
+// loaded.
+func (b *buffer) lineAt(i int) (string, int) {
+	d := b.docFor(i)
+	if d == nil {
+		return "", 0
+	}
+	rel := i - d.start
+	if rel < len(d.head) {
+		n := 0
+		if rel < len(d.numbers) {
+			n = d.numbers[rel]
+		}
+		return d.head[rel], n
+	}
+	k := sort.Search(len(d.blocks), func(k int) bool { return d.blocks[k].first+d.blocks[k].count > rel })
+	if k < len(d.blocks) {
+		blk := d.blocks[k]
+		lines, numbers := b.blockLines(d, blk)
+		j := rel - blk.first
+		n := 0
+		if j < len(numbers) {
+			n = numbers[j]
+		}
+		return lines[j], n
+	}
+	rel -= d.len() - len(d.footer)
+	if rel >= 0 && rel < len(d.footer) {
+		return d.footer[rel], 0
+	}
+	return "", 0
+}
+
+func (b *buffer) line(i int) string {
+	s, _ := b.lineAt(i)
+	return s
+}
+
+func (b *buffer) number(i int) int {
+	_, n := b.lineAt(i)
+	return n
+}
+
+func (b *buffer) docFor(line int) *docLines {
+	var last *docLines
+	for _, d := range b.docs {
+		if line < d.start {
+			break
+		}
+		last = d
+		if !d.done {
+			break
+		}
+	}
+	return last
+}
+
+// docAt returns the bounds of the document that contains line, as far as
+// it is loaded.
+func (b *buffer) docAt(line int) (start, end int) {
+	for _, d := range b.docs {
+		if line < d.start+d.len() || !d.done {
+			return d.start, d.start + d.len()
+		}
+	}
+	if n := len(b.docs); n > 0 {
+		d := b.docs[n-1]
+		return d.start, d.start + d.len()
+	}
+	return 0, 0
+}
+
+// fileAt returns the index of the document that contains line.
+func (b *buffer) fileAt(line int) int {
+	for i, d := range b.docs {
+		if line < d.start+d.len() || !d.done {
+			return i
+		}
+	}
+	return len(b.docs) - 1
+}
+
+// fileStart returns the first line of document k, reading the documents
+// before it to the end.
+func (b *buffer) fileStart(k int) int {
+	for _, d := range b.docs[:k] {
+		for !d.done && !d.waits() {
+			b.loadBlock(d)
+		}
+	}
+	return b.docs[k].start
+}
+
+// pinned returns the range of lines to keep at the top of the screen when
+// the document at offset has scrolled past its header.
+func (b *buffer) pinned(offset int) (first, last int) {
+	for _, d := range b.docs {
+		if offset < d.start+d.len() || !d.done {
+			s := d.sticky
+			if s[1] > s[0] && offset > d.start+s[0] {
+				return d.start + s[0], d.start + s[1]
+			}
+			return 0, 0
+		}
+	}
+	return 0, 0
+}
+
+func (b *buffer) numberedAt(line int) (*docLines, bool) {
+	for _, d := range b.docs {
+		if line < d.start+d.len() || !d.done {
+			return d, d.numbered
+		}
+	}
+	return nil, false
+}
+
+// position describes the visible range [offset, end) for the status line.
+// Numbered documents report gutter numbers; anything else falls back to
+// pager line positions. Totals that are not known yet end in "+".
+func (b *buffer) position(offset, end int) string {
+	more := ""
+	if !b.complete() {
+		more = "+"
+	}
+	d, numbered := b.numberedAt(offset)
+	if !numbered {
+		return fmt.Sprintf("%d-%d/%d%s", offset+1, end, b.Len(), more)
+	}
+	first, last := 0, 0
+	for i := offset; i < end; i++ {
+		if n := b.number(i); n > 0 {
+			if first == 0 {
+				first = n
+			}
+			last = n
+		}
+	}
+	final := 0
+	for i := d.start + d.len() - 1; i >= d.start && final == 0; i-- {
+		final = b.number(i)
+	}
+	if first == 0 {
+		// Nothing numbered in view: report the number before it.
+		for i := offset - 1; i >= d.start && last == 0; i-- {
+			last = b.number(i)
+		}
+		first = last
+	}
+	if d.done {
+		more = ""
+	}
+	return fmt.Sprintf("line %d-%d/%d%s", first, last, final, more)
+}
+
+// lineFor returns the pager line to show for line n of the document at
+// offset. Numbered documents are searched by gutter number, loading blocks
+// until the number is found.
+func (b *buffer) lineFor(offset, n int) int {
+	d, numbered := b.numberedAt(offset)
+	if d == nil {
+		return 0
+	}
+	if !numbered {
+		b.load(d.start + n)
+		return d.start + n - 1
+	}
+	for i, num := range d.numbers {
+		if i < len(d.head) && num >= n {
+			return d.start + i
+		}
+	}
+	for !d.done && !d.waits() && (len(d.blocks) == 0 || d.blocks[len(d.blocks)-1].last < n) {
+		b.loadBlock(d)
+	}
+	k := sort.Search(len(d.blocks), func(k int) bool { return d.blocks[k].last >= n })
+	if k < len(d.blocks) {
+		blk := d.blocks[k]
+		_, numbers := b.blockLines(d, blk)
+		for j, num := range numbers {
+			if num >= n {
+				return d.start + blk.first + j
+			}
+		}
+	}
+	return d.start + d.len() - 1
+}
+
+// close releases the streams that were not read to the end. Streams read
+// in the background are closed by the goroutine reading them.
+func (b *buffer) close() {
+	for _, d := range b.docs {
+		if !d.done && !d.waits() {
+			d.stream.Close()
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package pager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rodrwan/prettycat/internal/input"
+	"github.com/rodrwan/prettycat/internal/render"
+)
+
+func TestBufferUsesGutterNumbers(t *testing.T) {
+	buf := newBuffer([]render.Doc{
+		{Body: "==> a <==\n1  x\n\n2  y\n", Numbers: []int{0, 1, 0, 2}},
+		{Body: "==> b <==\n1  z\n", Numbers: []int{0, 1}},
+	})
+
+	if got := buf.position(0, 3); got != "line 1-1/2" {
+		t.Fatalf("position = %q", got)
+	}
+	if got := buf.lineFor(0, 2); got != 3 {
+		t.Fatalf("lineFor(2) = %d, want 3", got)
+	}
+	if got := buf.lineFor(4, 1); got != 5 {
+		t.Fatalf("lineFor in second doc = %d, want 5", got)
+	}
+}
+
+func TestBufferFallsBackToPagerLines(t *testing.T) {
+	buf := newBuffer([]render.Doc{{Body: "a\nb\nc\n"}})
+	if got := buf.position(1, 3); got != "2-3/3" {
+		t.Fatalf("position = %q", got)
+	}
+	if got := buf.lineFor(0, 3); got != 2 {
+		t.Fatalf("lineFor(3) = %d, want 2", got)
+	}
+}
+
+func TestRenderPagePinsStickyHeader(t *testing.T) {
+	buf := newBuffer([]render.Doc{{Body: "h\n-\na\nb\nc\nd\ne\n", Sticky: 2}})
+
+	got, _ := renderPage(buf, 4, 0, 4, view{}, nil, "")
+	if want := []string{"h", "-", "c", "d"}; strings.Join(got[:4], ",") != strings.Join(want, ",") {
+		t.Fatalf("page = %q, want header pinned above %q", got[:4], want[2:])
+	}
+
+	if got, _ := renderPage(buf, 0, 0, 4, view{}, nil, ""); got[2] != "a" {
+		t.Fatalf("header should not repeat at the top of the document, got %q", got)
+	}
+}
+
+// streamedDoc renders a file large enough to be streamed.
+func streamedDoc(t *testing.T, lines int, opts render.Options) render.Doc {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "big.txt")
+	var b strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&b, "line %06d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, _, err := input.Load([]string{path}, input.LoadOptions{}, os.Stdin, func(*os.File) bool { return true }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := render.Render(res.Sources[0], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Stream == nil {
+		t.Fatal("expected a streamed document")
+	}
+	return doc
+}
+
+func TestBufferLoadsStreamsLazily(t *testing.T) {
+	const total = 60000
+	doc := streamedDoc(t, total, render.Options{})
+	doc.Append("--\n")
+	buf := newBuffer([]render.Doc{doc, {Body: "next\n"}})
+	defer buf.close()
+
+	if n := buf.Len(); n >= total {
+		t.Fatalf("expected only the first block to be loaded, got %d lines", n)
+	}
+	if got := buf.position(0, 10); !strings.HasSuffix(got, "+") {
+		t.Fatalf("position before the end is known = %q", got)
+	}
+
+	buf.load(total - 10)
+	if got := buf.line(total - 11); got != fmt.Sprintf("line %06d", total-10) {
+		t.Fatalf("line(%d) = %q", total-11, got)
+	}
+	buf.loadAll()
+	if got := buf.Len(); got != total+2 {
+		t.Fatalf("Len() = %d, want %d", got, total+2)
+	}
+	if got := buf.line(total); got != "--" {
+		t.Fatalf("footer line = %q", got)
+	}
+	if got := buf.line(total + 1); got != "next" {
+		t.Fatalf("next document line = %q", got)
+	}
+	for _, blk := range buf.docs[0].blocks {
+		if blk.lines != nil {
+			t.Fatal("blocks of seekable files should not be kept")
+		}
+	}
+	if got := buf.line(len(buf.docs[0].head)); got != fmt.Sprintf("line %06d", len(buf.docs[0].head)+1) {
+		t.Fatalf("rerendered block line = %q", got)
+	}
+}
+
+func TestBufferFindsGutterNumbersInStreams(t *testing.T) {
+	const total = 60000
+	buf := newBuffer([]render.Doc{streamedDoc(t, total, render.Options{Number: render.NumberAll})})
+	defer buf.close()
+
+	i := buf.lineFor(0, 45000)
+	if got := buf.line(i); !strings.HasSuffix(got, "line 045000") {
+		t.Fatalf("lineFor(45000) shows %q", got)
+	}
+	if got := buf.position(i, i+2); !strings.HasPrefix(got, "line 45000-45001/") || !strings.HasSuffix(got, "+") {
+		t.Fatalf("position = %q", got)
+	}
+	buf.loadAll()
+	if got := buf.position(0, 1); got != fmt.Sprintf("line 1-1/%d", total) {
+		t.Fatalf("position = %q", got)
+	}
+}
+
+func TestBufferAppendsFollowedBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, _, err := input.Load([]string{path}, input.LoadOptions{Follow: true}, os.Stdin, func(*os.File) bool { return true }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := render.Render(res.Sources[0], render.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := newBuffer([]render.Doc{doc})
+	d := buf.followed()
+	if d == nil {
+		t.Fatal("expected a followed document")
+	}
+	buf.loadAll() // must not wait for appended data
+	if got := buf.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("three\n")
+	f.Close()
+
+	blocks := make(chan streamedBlock)
+	done := make(chan struct{})
+	defer close(done)
+	go readBlocks(d, blocks, done)
+	sb := <-blocks
+	buf.appendBlock(sb.doc, sb.block, sb.err)
+	if got := buf.Len(); got != 3 || buf.line(2) != "three" {
+		t.Fatalf("after append Len() = %d, line(2) = %q", got, buf.line(2))
+	}
+	if got := buf.position(0, 3); got != "1-3/3+" {
+		t.Fatalf("position = %q", got)
+	}
+}
+
+func TestBufferReadsPipesInTheBackground(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, "first\n")
+	res, _, err := input.Load(nil, input.LoadOptions{}, r, func(*os.File) bool { return false }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := render.Render(res.Sources[0], render.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := newBuffer([]render.Doc{doc})
+	d := buf.waiting()
+	if d == nil || d.following() {
+		t.Fatal("expected a pipe read in the background")
+	}
+
+	loaded := make(chan struct{})
+	go func() {
+		buf.load(100) // must not wait for the writer
+		buf.loadAll()
+		close(loaded)
+	}()
+	select {
+	case <-loaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("loading waited for the pipe")
+	}
+	if got := buf.Len(); got != 1 {
+		t.Fatalf("Len() = %d, want 1", got)
+	}
+
+	blocks := make(chan streamedBlock)
+	done := make(chan struct{})
+	defer close(done)
+	go readBlocks(d, blocks, done)
+	io.WriteString(w, "second\n")
+	w.Close()
+	for !d.done {
+		sb := <-blocks
+		buf.appendBlock(sb.doc, sb.block, sb.err)
+	}
+	if got := buf.Len(); got != 2 || buf.line(1) != "second" {
+		t.Fatalf("after the pipe ended Len() = %d, line(1) = %q", got, buf.line(1))
+	}
+}
+
+func TestBufferReflowKeepsTopLineAnchored(t *testing.T) {
+	src := input.Source{Name: "a.txt", Data: []byte("alpha beta gamma\ndelta epsilon\nzeta eta theta\n")}
+	doc, err := render.Render(src, render.Options{Width: 6, Wrap: render.WrapAlways, Number: render.NumberAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := newBuffer([]render.Doc{doc})
+	top := -1
+	for i := 0; i < buf.Len(); i++ {
+		if buf.number(i) == 3 {
+			top = i
+			break
+		}
+	}
+	a := buf.anchorAt(top + 1) // a continuation of line 3
+
+	buf.reflow(80)
+	if got := buf.Len(); got != 3 {
+		t.Fatalf("Len() after reflow = %d, want 3", got)
+	}
+	if got := buf.lineAnchored(a); got != 2 {
+		t.Fatalf("anchored line = %d, want 2", got)
+	}
+}
+
+func TestBufferFindsFileBoundaries(t *testing.T) {
+	const total = 60000
+	buf := newBuffer([]render.Doc{streamedDoc(t, total, render.Options{}), {Title: "b", Body: "b1\nb2\n"}, {Title: "c", Body: "c1\n"}})
+	defer buf.close()
+
+	if got := buf.fileAt(10); got != 0 {
+		t.Fatalf("fileAt(10) = %d, want 0", got)
+	}
+	if got := buf.fileStart(1); got != total {
+		t.Fatalf("fileStart(1) = %d, want %d", got, total)
+	}
+	if got := buf.fileStart(2); got != total+2 || buf.line(got) != "c1" {
+		t.Fatalf("fileStart(2) = %d (%q)", got, buf.line(got))
+	}
+	if got := buf.fileAt(total + 1); got != 1 {
+		t.Fatalf("fileAt(%d) = %d, want 1", total+1, got)
+	}
+	if got := buf.fileAt(total + 2); got != 2 {
+		t.Fatalf("fileAt(%d) = %d, want 2", total+2, got)
+	}
+}
+
+func TestRenderPageShowsMessagesOnNarrowTerminals(t *testing.T) {
+	buf := newBuffer([]render.Doc{{Title: "a.go", Body: "a\n"}, {Title: "b.go", Body: "b\n"}})
+	for _, msg := range []string{
+		`match 3/12 for "needle"`,
+		`no matches for "needle"`,
+		"invalid pattern: missing closing )",
+		"search canceled",
+		"following, k to stop",
+		"already at the last file",
+	} {
+		rows, _ := renderPage(buf, 0, 0, 4, view{width: 80, wrap: true}, nil, msg)
+		status := rows[len(rows)-1]
+		if !strings.Contains(status, msg) {
+			t.Errorf("status %q does not show %q", status, msg)
+		}
+		if w := len([]rune(status)); w > 80 {
+			t.Errorf("status is %d columns wide", w)
+		}
+	}
+}
+
+func TestFileCommandsNameShortFiles(t *testing.T) {
+	buf := newBuffer([]render.Doc{
+		{Title: "a.go", Body: strings.Repeat("a\n", 20)},
+		{Title: "b.go", Body: "b1\nb2\n"},
+		{Title: "c.go", Body: "c1\n"},
+	})
+	v := view{width: 80, wrap: true}
+	status := func(offset, file int) string {
+		rows, _ := renderPage(buf, offset, file, 10, v, nil, "")
+		return rows[len(rows)-1]
+	}
+
+	offset, file, msg := fileCommand(buf, ":n", 0, 0, 0, 10, v)
+	if offset != 13 || file != 1 || msg != "" {
+		t.Fatalf(":n = %d, %d, %q; want the last page, in b.go", offset, file, msg)
+	}
+	if s := status(offset, file); !strings.HasPrefix(s, "b.go (2/3)") {
+		t.Fatalf("status = %q, want b.go", s)
+	}
+	if offset, file, _ = fileCommand(buf, ":n", 0, offset, file, 10, v); file != 2 || offset != 13 {
+		t.Fatalf("second :n = %d, %d; want c.go at the same page", offset, file)
+	}
+	if _, _, msg = fileCommand(buf, ":n", 0, offset, file, 10, v); msg != "already at the last file" {
+		t.Fatalf("third :n says %q", msg)
+	}
+	if offset, file, _ = fileCommand(buf, ":p", 2, offset, file, 10, v); file != 0 || offset != 0 {
+		t.Fatalf("2:p = %d, %d; want the top of a.go", offset, file)
+	}
+}
@@ -0,0 +1,109 @@
+package pager
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// screen draws pager frames on the terminal's alternate screen. It keeps
+// the rows it last drew and only rewrites the ones that change; when the
+// view moves by one line, the unchanged rows are scrolled in place instead
+// of being drawn again.
+type screen struct {
+	mu     sync.Mutex
+	out    io.Writer
+	rows   []string // what the terminal shows, nil when unknown
+	active bool
+}
+
+func newScreen(out io.Writer) *screen {
+	return &screen{out: out}
+}
+
+// enter switches to the alternate screen, hiding the cursor and turning
+// off line wrapping so every frame row takes one terminal row.
+func (s *screen) enter() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(s.out, "\x1b[?1049h\x1b[?25l\x1b[?7l\x1b[2J\x1b[H")
+	s.active, s.rows = true, nil
+}
+
+// leave restores the screen the pager was started from. It is safe to call
+// more than once, and from a signal handler.
+func (s *screen) leave() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.active {
+		return
+	}
+	io.WriteString(s.out, "\x1b[r\x1b[?7h\x1b[?25h\x1b[?1049l")
+	s.active = false
+}
+
+// invalidate makes the next draw repaint every row, after the terminal was
+// resized.
+func (s *screen) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows = nil
+}
+
+func (s *screen) draw(frame []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var b strings.Builder
+	if s.rows == nil {
+		b.WriteString("\x1b[2J")
+		s.rows = make([]string, len(frame))
+	}
+	for len(s.rows) < len(frame) {
+		s.rows = append(s.rows, "")
+	}
+	s.scroll(&b, frame)
+	for i, row := range s.rows {
+		want := ""
+		if i < len(frame) {
+			want = frame[i]
+		}
+		if row != want {
+			// Clear first: without autowrap, the cursor stays on the last
+			// column of a full row, and clearing after it would erase it.
+			fmt.Fprintf(&b, "\x1b[%d;1H\x1b[K%s\x1b[0m", i+1, want)
+		}
+	}
+	s.rows = append(s.rows[:0], frame...)
+	fmt.Fprintf(&b, "\x1b[%d;1H", len(frame))
+	io.WriteString(s.out, b.String())
+}
+
+// scroll moves the rows the frame shows one line up or down by scrolling
+// the terminal region that holds them, and updates rows to match.
+func (s *screen) scroll(b *strings.Builder, frame []string) {
+	top := 0
+	for top < len(frame) && s.rows[top] == frame[top] {
+		top++
+	}
+	up, down := 0, 0
+	for i := top; i+1 < len(s.rows) && i < len(frame) && frame[i] == s.rows[i+1]; i++ {
+		up++
+	}
+	for i := top; i+1 < len(frame) && i < len(s.rows) && frame[i+1] == s.rows[i]; i++ {
+		down++
+	}
+	if max(up, down) < 2 {
+		return
+	}
+	if up >= down {
+		// Rows top+1..top+up move to top..top+up-1.
+		fmt.Fprintf(b, "\x1b[%d;%dr\x1b[%d;1H\x1b[S\x1b[r", top+1, top+up+1, top+1)
+		copy(s.rows[top:], s.rows[top+1:top+up+1])
+		s.rows[top+up] = ""
+		return
+	}
+	fmt.Fprintf(b, "\x1b[%d;%dr\x1b[%d;1H\x1b[T\x1b[r", top+1, top+down+1, top+1)
+	copy(s.rows[top+1:top+down+1], s.rows[top:top+down])
+	s.rows[top] = ""
+}
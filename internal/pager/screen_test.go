@@ -0,0 +1,162 @@
+package pager
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// vt applies the escapes the screen writes to a grid of cells. Like a
+// terminal without autowrap, it leaves the cursor on the last column after
+// writing there.
+type vt struct {
+	rows          [][]rune
+	width         int
+	row, col      int
+	top, bottom   int
+	alt, autowrap bool
+}
+
+var escRe = regexp.MustCompile(`^\x1b\[(\??)([0-9;]*)([A-Za-z])`)
+
+func newVT(width, height int) *vt {
+	return &vt{rows: make([][]rune, height), width: width, bottom: height - 1, autowrap: true}
+}
+
+func (t *vt) line(r int) string {
+	return string(t.rows[r])
+}
+
+func (t *vt) write(s string) {
+	for s != "" {
+		m := escRe.FindStringSubmatch(s)
+		if m == nil {
+			i := strings.IndexByte(s, '\x1b')
+			if i < 0 {
+				i = len(s)
+			}
+			for _, r := range s[:i] {
+				row := t.rows[t.row]
+				for len(row) <= t.col {
+					row = append(row, ' ')
+				}
+				row[t.col] = r
+				t.rows[t.row] = row
+				t.col = min(t.col+1, t.width-1)
+			}
+			s = s[i:]
+			continue
+		}
+		s = s[len(m[0]):]
+		args := strings.Split(m[2], ";")
+		arg := func(i, def int) int {
+			if i < len(args) {
+				if n, err := strconv.Atoi(args[i]); err == nil {
+					return n
+				}
+			}
+			return def
+		}
+		switch {
+		case m[1] == "?":
+			on := m[3] == "h"
+			switch m[2] {
+			case "1049":
+				t.alt = on
+			case "7":
+				t.autowrap = on
+			}
+		case m[3] == "H":
+			t.row, t.col = arg(0, 1)-1, arg(1, 1)-1
+		case m[3] == "K":
+			if t.col < len(t.rows[t.row]) {
+				t.rows[t.row] = t.rows[t.row][:t.col]
+			}
+		case m[3] == "J":
+			t.rows = make([][]rune, len(t.rows))
+		case m[3] == "r":
+			t.top, t.bottom = arg(0, 1)-1, arg(1, len(t.rows))-1
+			t.row, t.col = 0, 0
+		case m[3] == "S":
+			copy(t.rows[t.top:t.bottom], t.rows[t.top+1:t.bottom+1])
+			t.rows[t.bottom] = nil
+		case m[3] == "T":
+			copy(t.rows[t.top+1:t.bottom+1], t.rows[t.top:t.bottom])
+			t.rows[t.top] = nil
+		case m[3] == "m":
+		default:
+			panic("unexpected escape " + m[0])
+		}
+	}
+}
+
+func page(first, n int) []string {
+	var rows []string
+	for i := first; i < first+n; i++ {
+		rows = append(rows, fmt.Sprintf("line %d", i))
+	}
+	return append(rows, fmt.Sprintf("status %d", first))
+}
+
+func TestScreenRedrawsOnlyChangedRows(t *testing.T) {
+	var out strings.Builder
+	term := newVT(20, 10)
+	s := newScreen(&out)
+	s.enter()
+	if term.write(out.String()); !term.alt || term.autowrap {
+		t.Fatalf("enter should switch to the alternate screen without autowrap")
+	}
+
+	frames := [][]string{
+		page(1, 8),
+		page(2, 8), // one line down
+		page(1, 8), // one line up
+		append([]string{"header"}, page(5, 7)...), // pinned header above a shifted view
+		append([]string{"header"}, page(6, 7)...),
+		{"short", "status"},
+	}
+	for i, frame := range frames {
+		out.Reset()
+		s.draw(frame)
+		written := out.String()
+		term.write(written)
+		for r, want := range append(frame, make([]string, 10-len(frame))...) {
+			if got := term.line(r); got != want {
+				t.Fatalf("frame %d: row %d = %q, want %q", i, r, got, want)
+			}
+		}
+		if i == 1 && strings.Count(written, "line") != 1 {
+			t.Fatalf("scrolling one line should draw a single content row, wrote %q", written)
+		}
+	}
+
+	out.Reset()
+	s.draw(frames[len(frames)-1])
+	if strings.Contains(out.String(), "short") {
+		t.Fatalf("an unchanged frame should not be drawn again, wrote %q", out.String())
+	}
+
+	out.Reset()
+	s.leave()
+	s.leave()
+	if term.write(out.String()); term.alt || !term.autowrap {
+		t.Fatalf("leave should restore the original screen")
+	}
+}
+
+func TestScreenKeepsTheLastColumn(t *testing.T) {
+	var out strings.Builder
+	term := newVT(6, 3)
+	s := newScreen(&out)
+	s.enter()
+	s.draw([]string{"abcde›", "\x1b[31mxyzxyz\x1b[0m", "status"})
+	s.draw([]string{"abcdef", "\x1b[31mxyzxyz\x1b[0m", "status"})
+	term.write(out.String())
+	for r, want := range []string{"abcdef", "xyzxyz", "status"} {
+		if got := term.line(r); got != want {
+			t.Fatalf("row %d = %q, want %q", r, got, want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package pager
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+// search is the state of the search prompt and of the last search.
+type search struct {
+	prompting bool   // the prompt is open
+	backward  bool   // opened with ?
+	fileOnly  bool   // search the current file, not every file
+	at        int    // line the search started from
+	lo, hi    int    // lines searched
+	partial   bool   // only lines around at were searched
+	input     string // typed at the prompt
+	query     string
+	re        *regexp.Regexp
+	matches   []match
+	current   int
+}
+
+// match is a match of the search in the visible text of a line, as byte
+// offsets into the line without its escape sequences.
+type match struct {
+	line       int
+	start, end int
+}
+
+// compileQuery compiles q as a regular expression. It ignores case unless
+// q has an upper case letter (smart case).
+func compileQuery(q string) (*regexp.Regexp, error) {
+	if !hasUpper(q) {
+		q = "(?i)" + q
+	}
+	re, err := regexp.Compile(q)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+	return re, nil
+}
+
+// hasUpper reports whether q has an upper case letter, not counting escapes
+// such as \S or \W.
+func hasUpper(q string) bool {
+	escaped := false
+	for _, r := range q {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case unicode.IsUpper(r):
+			return true
+		}
+	}
+	return false
+}
+
+// searchWindow is how many lines around the view are searched while the
+// pattern is typed. The rest is read and searched on Enter.
+const searchWindow = 1000
+
+// run searches for query in every line, or with fileOnly in the lines of
+// the file that contains line at, and makes the first match current.
+// Without all, only the lines within searchWindow of at are searched, so
+// that typing does not read a large stream to the end.
+func (s *search) run(buf *buffer, query string, at int, all bool) error {
+	s.query, s.re, s.matches, s.current, s.at, s.partial = query, nil, nil, 0, at, false
+	if query == "" {
+		return nil
+	}
+	re, err := compileQuery(query)
+	if err != nil {
+		return err
+	}
+	s.re = re
+	if all {
+		buf.loadAll()
+	} else {
+		buf.load(at + searchWindow)
+	}
+	s.lo, s.hi = 0, buf.Len()
+	if s.fileOnly {
+		s.lo, s.hi = buf.docAt(at)
+	}
+	if !all {
+		lo, hi := max(s.lo, at-searchWindow), min(s.hi, at+searchWindow)
+		s.partial = lo > s.lo || hi < s.hi || !buf.complete()
+		s.lo, s.hi = lo, hi
+	}
+	for i := s.lo; i < s.hi; i++ {
+		plain := style.Strip(buf.line(i))
+		for _, m := range re.FindAllStringIndex(plain, -1) {
+			if m[0] < m[1] {
+				s.matches = append(s.matches, match{line: i, start: m[0], end: m[1]})
+			}
+		}
+	}
+	return nil
+}
+
+// complete searches the lines a partial search left out, keeping the
+// current match.
+func (s *search) complete(buf *buffer) {
+	if !s.partial {
+		return
+	}
+	var cur match
+	if len(s.matches) > 0 {
+		cur = s.matches[s.current]
+	}
+	s.run(buf, s.query, s.at, true)
+	for i, m := range s.matches {
+		if m == cur {
+			s.current = i
+		}
+	}
+}
+
+// from makes the first match at or after line current, or with backward
+// the last one before it, wrapping around at the ends.
+func (s *search) from(line int, backward bool) (match, bool) {
+	if len(s.matches) == 0 {
+		return match{}, false
+	}
+	i := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].line >= line })
+	switch {
+	case backward && i == 0:
+		i = len(s.matches) - 1
+	case backward:
+		i--
+	case i == len(s.matches):
+		i = 0
+	}
+	s.current = i
+	return s.matches[i], true
+}
+
+// next makes the match delta positions away current, wrapping around.
+func (s *search) next(delta int) (match, bool) {
+	if len(s.matches) == 0 {
+		return match{}, false
+	}
+	s.current = ((s.current+delta)%len(s.matches) + len(s.matches)) % len(s.matches)
+	return s.matches[s.current], true
+}
+
+func (s *search) status() string {
+	switch {
+	case s.query == "" || s.re == nil:
+		return ""
+	case len(s.matches) == 0 && s.partial:
+		return fmt.Sprintf("no matches nearby for %q%s", s.query, s.scope())
+	case len(s.matches) == 0:
+		return fmt.Sprintf("no matches for %q%s", s.query, s.scope())
+	}
+	more := ""
+	if s.partial {
+		more = "+"
+	}
+	return fmt.Sprintf("match %d/%d%s for %q%s", s.current+1, len(s.matches), more, s.query, s.scope())
+}
+
+func (s *search) scope() string {
+	if s.fileOnly {
+		return " in this file"
+	}
+	return ""
+}
+
+// highlight shows the matches in line i in reverse video, and underlines
+// the current one.
+func (s *search) highlight(i int, line string) string {
+	if s == nil || s.re == nil || i < s.lo || i >= s.hi {
+		return line
+	}
+	spans := s.re.FindAllStringIndex(style.Strip(line), -1)
+	if len(spans) == 0 {
+		return line
+	}
+	cur := match{line: -1}
+	if len(s.matches) > 0 {
+		cur = s.matches[s.current]
+	}
+
+	var b strings.Builder
+	plain := 0 // offset into the line without escapes
+	k := 0     // next span
+	on := ""   // escape that starts the span being written
+	for j := 0; j <= len(line); {
+		for on != "" && plain == spans[k][1] {
+			b.WriteString("\x1b[27;24m")
+			on = ""
+			k++
+		}
+		for on == "" && k < len(spans) && spans[k][0] == spans[k][1] {
+			k++
+		}
+		if on == "" && k < len(spans) && plain == spans[k][0] {
+			on = "\x1b[7m"
+			if i == cur.line && spans[k][0] == cur.start {
+				on = "\x1b[7;4m"
+			}
+			b.WriteString(on)
+		}
+		if j == len(line) {
+			break
+		}
+		if n := style.EscapeLen(line[j:]); n > 0 {
+			b.WriteString(line[j : j+n])
+			if on != "" {
+				// The line's own escapes may reset the attributes.
+				b.WriteString(on)
+			}
+			j += n
+			continue
+		}
+		b.WriteByte(line[j])
+		j++
+		plain++
+	}
+	return b.String()
+}
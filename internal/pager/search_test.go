@@ -0,0 +1,134 @@
+package pager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/render"
+)
+
+func TestSearchMatchesVisibleText(t *testing.T) {
+	buf := newBuffer([]render.Doc{{Body: "\x1b[38;5;81mfunc\x1b[0m main() {}\n38;5 is not text\nFunc and func\n"}})
+	s := &search{}
+	if err := s.run(buf, "38", 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.matches) != 1 || s.matches[0].line != 1 {
+		t.Fatalf("escape sequences should not match, got %+v", s.matches)
+	}
+
+	if err := s.run(buf, `func\b`, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	want := []match{{0, 0, 4}, {2, 0, 4}, {2, 9, 13}}
+	if len(s.matches) != len(want) {
+		t.Fatalf("smart case matches = %+v, want %+v", s.matches, want)
+	}
+	for i := range want {
+		if s.matches[i] != want[i] {
+			t.Fatalf("match %d = %+v, want %+v", i, s.matches[i], want[i])
+		}
+	}
+	if err := s.run(buf, "Func", 0, true); err != nil || len(s.matches) != 1 {
+		t.Fatalf("an upper case letter should make the search case sensitive, got %+v", s.matches)
+	}
+	if err := s.run(buf, "(", 0, true); err == nil {
+		t.Fatal("expected an invalid pattern error")
+	}
+}
+
+func TestSearchNextWalksMatchesInALine(t *testing.T) {
+	s := &search{matches: []match{{0, 0, 1}, {2, 0, 4}, {2, 9, 13}}}
+	for _, want := range []int{1, 2, 0} {
+		if m, _ := s.next(1); m != s.matches[want] {
+			t.Fatalf("next = %+v, want match %d", m, want)
+		}
+	}
+	if m, _ := s.next(-1); m != s.matches[2] {
+		t.Fatalf("previous = %+v, want the last match", m)
+	}
+}
+
+func TestSearchHighlightKeepsLineColors(t *testing.T) {
+	buf := newBuffer([]render.Doc{{Body: "a \x1b[31mred\x1b[0m word red\n"}})
+	s := &search{}
+	if err := s.run(buf, "red w", 0, true); err != nil {
+		t.Fatal(err)
+	}
+	got := s.highlight(0, buf.line(0))
+	want := "a \x1b[7;4m\x1b[31m\x1b[7;4mred\x1b[0m\x1b[7;4m w\x1b[27;24mord red"
+	if got != want {
+		t.Fatalf("highlight = %q, want %q", got, want)
+	}
+	if s.highlight(1, "no match here") != "no match here" {
+		t.Fatal("lines without matches should not change")
+	}
+}
+
+func TestSearchFromStartsAtTheView(t *testing.T) {
+	s := &search{matches: []match{{1, 0, 1}, {4, 0, 1}, {4, 3, 4}, {9, 0, 1}}}
+	tests := []struct {
+		line     int
+		backward bool
+		want     int
+	}{
+		{line: 2, want: 1},
+		{line: 4, want: 1},
+		{line: 10, want: 0},
+		{line: 4, backward: true, want: 0},
+		{line: 9, backward: true, want: 2},
+		{line: 1, backward: true, want: 3},
+	}
+	for _, tc := range tests {
+		if m, ok := s.from(tc.line, tc.backward); !ok || m != s.matches[tc.want] {
+			t.Errorf("from(%d, %v) = %+v, want match %d", tc.line, tc.backward, m, tc.want)
+		}
+	}
+}
+
+func TestSearchWithinTheCurrentFile(t *testing.T) {
+	buf := newBuffer([]render.Doc{{Body: "x\ny\n"}, {Body: "x\nx\n"}, {Body: "x\n"}})
+	s := &search{}
+	if err := s.run(buf, "x", 3, true); err != nil || len(s.matches) != 4 {
+		t.Fatalf("search across files = %+v, %v", s.matches, err)
+	}
+	s.fileOnly = true
+	if err := s.run(buf, "x", 3, true); err != nil || len(s.matches) != 2 || s.matches[0].line != 2 {
+		t.Fatalf("search in the second file = %+v, %v", s.matches, err)
+	}
+	if got := s.highlight(0, "x"); got != "x" {
+		t.Fatalf("lines of other files should not be highlighted, got %q", got)
+	}
+	if got := s.status(); got != `match 1/2 for "x" in this file` {
+		t.Fatalf("status = %q", got)
+	}
+}
+
+func TestIncrementalSearchStaysNearTheView(t *testing.T) {
+	const total = 60000
+	buf := newBuffer([]render.Doc{streamedDoc(t, total, render.Options{})})
+	defer buf.close()
+
+	s := &search{}
+	if err := s.run(buf, "0$", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if n := buf.Len(); n >= total {
+		t.Fatalf("typing a pattern read the whole stream (%d lines)", n)
+	}
+	if !s.partial || len(s.matches) != searchWindow/10 {
+		t.Fatalf("partial search found %d matches, partial = %v", len(s.matches), s.partial)
+	}
+	if got := s.status(); !strings.Contains(got, "+") {
+		t.Fatalf("status of a partial search = %q", got)
+	}
+
+	s.next(3)
+	s.complete(buf)
+	if s.partial || len(s.matches) != total/10 {
+		t.Fatalf("complete search found %d matches, partial = %v", len(s.matches), s.partial)
+	}
+	if s.current != 3 {
+		t.Fatalf("current match = %d after completing, want 3", s.current)
+	}
+}
@@ -0,0 +1,105 @@
+//go:build linux
+
+package pager
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func openPTY(t *testing.T) (master, slave *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("open /dev/ptmx: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	var unlock int32
+	if err := ioctl(int(master.Fd()), syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		t.Fatalf("unlock pty: %v", err)
+	}
+	var n uint32
+	if err := ioctl(int(master.Fd()), syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		t.Fatalf("get pty number: %v", err)
+	}
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("open pty slave: %v", err)
+	}
+	t.Cleanup(func() { slave.Close() })
+	return master, slave
+}
+
+func TestMakeRawAndRestore(t *testing.T) {
+	_, slave := openPTY(t)
+	fd := int(slave.Fd())
+
+	before, err := getTermios(fd)
+	if err != nil {
+		t.Fatalf("getTermios: %v", err)
+	}
+	if before.Lflag&syscall.ICANON == 0 {
+		t.Fatalf("expected fresh pty to be in canonical mode")
+	}
+
+	restore, err := makeRaw(fd)
+	if err != nil {
+		t.Fatalf("makeRaw: %v", err)
+	}
+	raw, err := getTermios(fd)
+	if err != nil {
+		t.Fatalf("getTermios after makeRaw: %v", err)
+	}
+	if raw.Lflag&(syscall.ECHO|syscall.ICANON|syscall.ISIG) != 0 {
+		t.Fatalf("raw mode still has ECHO/ICANON/ISIG set: lflag=%#x", raw.Lflag)
+	}
+	if raw.Cc[syscall.VMIN] != 1 || raw.Cc[syscall.VTIME] != 0 {
+		t.Fatalf("raw mode VMIN/VTIME = %d/%d, want 1/0", raw.Cc[syscall.VMIN], raw.Cc[syscall.VTIME])
+	}
+
+	restore()
+	after, err := getTermios(fd)
+	if err != nil {
+		t.Fatalf("getTermios after restore: %v", err)
+	}
+	if after.Lflag != before.Lflag || after.Iflag != before.Iflag {
+		t.Fatalf("restore did not bring back original flags: got lflag=%#x iflag=%#x, want lflag=%#x iflag=%#x",
+			after.Lflag, after.Iflag, before.Lflag, before.Iflag)
+	}
+}
+
+func TestTTYSize(t *testing.T) {
+	master, slave := openPTY(t)
+
+	ws := &winsize{Row: 42, Col: 132}
+	if err := ioctl(int(master.Fd()), syscall.TIOCSWINSZ, unsafe.Pointer(ws)); err != nil {
+		t.Fatalf("set winsize: %v", err)
+	}
+
+	cols, rows, err := ttySize(int(slave.Fd()))
+	if err != nil {
+		t.Fatalf("ttySize: %v", err)
+	}
+	if cols != 132 || rows != 42 {
+		t.Fatalf("ttySize = %dx%d, want 132x42", cols, rows)
+	}
+}
+
+func TestMakeRawRejectsNonTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "notatty")
+	if err != nil {
+		t.Fatalf("create temp: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := makeRaw(int(f.Fd())); err == nil {
+		t.Fatalf("makeRaw on a regular file should fail")
+	}
+	if _, _, err := ttySize(int(f.Fd())); err == nil {
+		t.Fatalf("ttySize on a regular file should fail")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package pager
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoTerminal = errors.New("terminal control is not supported on this platform")
+
+func ttySize(fd int) (cols, rows int, err error) {
+	return 0, 0, errNoTerminal
+}
+
+func makeRaw(fd int) (func(), error) {
+	return func() {}, errNoTerminal
+}
+
+func resizeSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package pager
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	Row    uint16
+	Col    uint16
+	Xpixel uint16
+	Ypixel uint16
+}
+
+func ttySize(fd int) (cols, rows int, err error) {
+	ws := &winsize{}
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(ws)); err != nil {
+		return 0, 0, err
+	}
+	if ws.Row == 0 || ws.Col == 0 {
+		return 0, 0, fmt.Errorf("terminal size is %dx%d", ws.Col, ws.Row)
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// resizeSignals are the signals sent when the terminal is resized.
+func resizeSignals() []os.Signal {
+	return []os.Signal{syscall.SIGWINCH}
+}
+
+func makeRaw(fd int) (func(), error) {
+	orig, err := getTermios(fd)
+	if err != nil {
+		return func() {}, err
+	}
+	raw := *orig
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Cflag |= syscall.CS8
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, &raw); err != nil {
+		return func() {}, err
+	}
+	return func() {
+		_ = setTermios(fd, orig)
+	}, nil
+}
+
+func getTermios(fd int) (*syscall.Termios, error) {
+	termios := &syscall.Termios{}
+	if err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(termios)); err != nil {
+		return nil, err
+	}
+	return termios, nil
+}
+
+func setTermios(fd int, termios *syscall.Termios) error {
+	return ioctl(fd, ioctlSetTermios, unsafe.Pointer(termios))
+}
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(
+		uintptr(syscall.SYS_IOCTL),
+		uintptr(fd),
+		req,
+		uintptr(arg),
+	)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -5,160 +5,458 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
-	"unsafe"
 
+	"github.com/rodrwan/prettycat/internal/exitcode"
 	"github.com/rodrwan/prettycat/internal/render"
 )
 
-func Run(docs []render.Doc, color bool, stdout io.Writer) error {
-	content := joinDocs(docs)
-	lines := strings.Split(content, "\n")
-	height := terminalHeight()
+// Options configures the pager.
+type Options struct {
+	Color bool
+	// Reflow renders the documents again when the terminal width changes.
+	Reflow bool
+}
+
+func Run(docs []render.Doc, opts Options, stdout io.Writer) error {
+	buf := newBuffer(docs)
+	defer buf.close()
+	width, height := terminalSize()
+	v := view{width: width, wrap: true, color: opts.Color}
 
 	offset := 0
-	query := ""
-	matches := []int{}
-	matchIdx := 0
+	srch := &search{}
+	// The search and view to go back to when the prompt is canceled.
+	var saved search
+	origin, originCol := 0, 0
+	// The first key of :n, :p, ]f and [f, and the count typed before it.
+	prefix, prefixCount := "", 0
+	// The file :n and :p last moved to. The status names it while the view
+	// stays there, even when the file is too short to scroll to its top.
+	file, fileOffset := 0, -1
+	currentFile := func() int {
+		if offset == fileOffset {
+			return file
+		}
+		return buf.fileAt(offset)
+	}
 	statusExtra := ""
-	searching := false
-	searchInput := ""
+	count := ""
 
-	restore, err := makeRaw(int(os.Stdin.Fd()))
-	if err == nil {
-		defer restore()
+	keyboard, restoreKeyboard := openKeyboard()
+	scr := newScreen(stdout)
+	scr.enter()
+	// Once, because a signal may arrive while the deferred call runs; the
+	// later caller waits for the terminal to be restored.
+	restore := sync.OnceFunc(func() {
+		scr.leave()
+		restoreKeyboard()
+	})
+	// Deferred calls also run when the pager panics.
+	defer restore()
+	stop := restoreOnSignal(restore)
+	defer stop()
+
+	resized := make(chan os.Signal, 1)
+	if sigs := resizeSignals(); len(sigs) > 0 {
+		signal.Notify(resized, sigs...)
+		defer signal.Stop(resized)
+	}
+
+	keys := make(chan keyEvent)
+	go readKeys(bufio.NewReader(keyboard), keys)
+
+	// Pipes and followed files are read in the background, so that the
+	// pager draws what has arrived and keeps taking keys meanwhile. A
+	// followed document stays at the bottom, like less +F, until the user
+	// scrolls up.
+	var blocks chan streamedBlock
+	following := false
+	if d := buf.waiting(); d != nil {
+		blocks = make(chan streamedBlock)
+		done := make(chan struct{})
+		defer close(done)
+		go readBlocks(d, blocks, done)
+		following = d.following()
 	}
 
-	r := bufio.NewReader(os.Stdin)
 	for {
-		pageSize := computePageSize(height, searching)
-		renderPage(stdout, lines, offset, pageSize, color, statusExtra, searching, searchInput)
+		pageSize := computePageSize(height, srch.prompting)
+		if following {
+			offset = lastOffset(buf, pageSize, v)
+		}
+		buf.load(offset + 2*pageSize)
+		offset = min(offset, lastOffset(buf, pageSize, v))
+		switch {
+		case buf.followed() == nil:
+		case following:
+			statusExtra = joinStatus("following, k to stop", statusExtra)
+		default:
+			statusExtra = joinStatus("F to follow", statusExtra)
+		}
+		page, next := renderPage(buf, offset, currentFile(), pageSize, v, srch, statusExtra)
+		scr.draw(page)
 		statusExtra = ""
 
-		key, err := readKey(r)
+		var ev keyEvent
+		select {
+		case ev = <-keys:
+		case <-resized:
+			w, h := terminalSize()
+			if opts.Reflow && w != width {
+				// Keep the top line in view while wrapped lines reflow.
+				a := buf.anchorAt(offset)
+				buf.reflow(w)
+				offset = buf.lineAnchored(a)
+			}
+			width, height = w, h
+			v.width = w
+			scr.invalidate()
+			continue
+		case sb := <-blocks:
+			buf.appendBlock(sb.doc, sb.block, sb.err)
+			if sb.err != nil {
+				blocks = nil
+			}
+			continue
+		}
+		key, err := ev.key, ev.err
 		if err != nil {
 			if err == io.EOF {
 				return nil
 			}
 			return err
 		}
+		atBottom := offset >= lastOffset(buf, pageSize, v)
 
-		if searching {
+		if srch.prompting {
 			switch key {
 			case "enter":
-				searching = false
-				query = strings.TrimSpace(searchInput)
-				matches = findMatches(lines, query)
-				matchIdx = 0
-				if len(matches) > 0 {
-					offset = matches[0]
-					statusExtra = fmt.Sprintf("match 1/%d for %q", len(matches), query)
-				} else if query != "" {
-					statusExtra = fmt.Sprintf("no matches for %q", query)
+				srch.prompting = false
+				if srch.input == "" {
+					// An empty pattern keeps the previous search.
+					*srch = saved
+					break
 				}
+				if srch.re == nil {
+					_, err := compileQuery(srch.input)
+					statusExtra = err.Error()
+					break
+				}
+				if srch.partial {
+					// Only lines near the view were searched while typing.
+					srch.complete(buf)
+					if m, ok := srch.from(origin, srch.backward); ok {
+						following = false
+						offset, v.col = m.line, originCol
+						v = v.reveal(buf.line(m.line), m)
+					}
+				}
+				statusExtra = srch.status()
 			case "esc":
-				searching = false
-				searchInput = ""
+				*srch = saved
+				offset, v.col = origin, originCol
 				statusExtra = "search canceled"
-			case "backspace":
-				if len(searchInput) > 0 {
-					searchInput = searchInput[:len(searchInput)-1]
-				}
 			default:
-				if len(key) == 1 && key[0] >= 32 && key[0] <= 126 {
-					searchInput += key
+				switch {
+				case key == "backspace" && len(srch.input) > 0:
+					srch.input = srch.input[:len(srch.input)-1]
+				case len(key) == 1 && key[0] >= 32 && key[0] <= 126:
+					srch.input += key
+				case key == "tab" && len(buf.docs) > 1:
+					srch.fileOnly = !srch.fileOnly
+				default:
+					continue
 				}
+				// Search as the pattern is typed, from where the prompt was
+				// opened.
+				offset, v.col = origin, originCol
+				if err := srch.run(buf, srch.input, origin, false); err != nil {
+					statusExtra = err.Error()
+					continue
+				}
+				if m, ok := srch.from(origin, srch.backward); ok {
+					following = false
+					offset = m.line
+					v = v.reveal(buf.line(m.line), m)
+				}
+				statusExtra = srch.status()
+			}
+			continue
+		}
+
+		if prefix != "" {
+			seq, n := prefix+key, prefixCount
+			prefix = ""
+			old := offset
+			offset, file, statusExtra = fileCommand(buf, seq, n, offset, currentFile(), pageSize, v)
+			fileOffset = offset
+			if offset != old {
+				following = false
 			}
 			continue
 		}
 
+		if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (count != "" || key != "0") {
+			count += key
+			statusExtra = ":" + count
+			continue
+		}
+		n, _ := strconv.Atoi(count)
+		count = ""
+
 		switch key {
-		case "q":
+		case "q", "ctrl-c":
 			return nil
 		case "j", "down":
-			if offset+1 < len(lines) {
+			if offset+1 < buf.Len() {
 				offset++
 			}
 		case "k", "up":
+			following = false
 			if offset > 0 {
 				offset--
 			}
 		case "f", "pgdn", "space":
-			offset += pageSize
-			if offset >= len(lines) {
-				offset = max(0, len(lines)-1)
+			// Wrapped lines take several rows, so the next page starts
+			// at the first line this one did not show in full.
+			offset = max(next, offset+1)
+			buf.load(offset + 1)
+			if offset >= buf.Len() {
+				offset = max(0, buf.Len()-1)
 			}
 		case "b", "pgup":
-			offset -= pageSize
-			if offset < 0 {
+			following = false
+			// The pinned header takes rows from the page above too.
+			offset = pageUp(buf, offset, pageSize-pinnedRows(buf, offset, v), v)
+		case "g", "G":
+			switch {
+			case n > 0:
+				offset = max(0, buf.lineFor(offset, n))
+			case key == "g":
+				following = false
 				offset = 0
+			default:
+				buf.loadAll()
+				offset = lastOffset(buf, pageSize, v)
 			}
-		case "g":
-			offset = 0
-		case "G":
-			offset = max(0, len(lines)-pageSize)
-		case "/":
-			searching = true
-			searchInput = ""
-			statusExtra = "type search and press Enter"
-		case "n":
-			if len(matches) > 0 {
-				matchIdx = (matchIdx + 1) % len(matches)
-				offset = matches[matchIdx]
-				statusExtra = fmt.Sprintf("match %d/%d for %q", matchIdx+1, len(matches), query)
+		case "w":
+			v.wrap, v.col = !v.wrap, 0
+			if v.wrap {
+				statusExtra = "wrapping long lines"
+			} else {
+				statusExtra = "cutting long lines"
 			}
-		case "N":
-			if len(matches) > 0 {
-				matchIdx--
-				if matchIdx < 0 {
-					matchIdx = len(matches) - 1
-				}
-				offset = matches[matchIdx]
-				statusExtra = fmt.Sprintf("match %d/%d for %q", matchIdx+1, len(matches), query)
+		case "h", "left", "l", "right":
+			if v.wrap {
+				statusExtra = "w to stop wrapping and scroll sideways"
+				break
+			}
+			step := hscrollStep
+			if n > 0 {
+				step = n
+			}
+			if key == "h" || key == "left" {
+				step = -step
 			}
+			v.col = max(0, v.col+step)
+		case "F":
+			following = blocks != nil
+			if !following {
+				statusExtra = "nothing to follow"
+			}
+		case ":", "[", "]":
+			prefix, prefixCount = key, n
+			statusExtra = key
+		case "/", "?":
+			saved, origin, originCol = *srch, offset, v.col
+			srch.prompting, srch.backward, srch.input = true, key == "?", ""
+		case "n", "N":
+			// n repeats the search in its direction, N goes the other way.
+			delta := max(n, 1)
+			if (key == "N") != srch.backward {
+				delta = -delta
+			}
+			srch.complete(buf)
+			if m, ok := srch.next(delta); ok {
+				offset = m.line
+				v = v.reveal(buf.line(m.line), m)
+				statusExtra = srch.status()
+			}
+		}
+
+		buf.load(offset + pageSize)
+		if offset > lastOffset(buf, pageSize, v) {
+			offset = lastOffset(buf, pageSize, v)
+		}
+		if following && atBottom && offset < lastOffset(buf, pageSize, v) {
+			// A line number or search match above the end stops following.
+			following = false
 		}
+	}
+}
+
+type keyEvent struct {
+	key string
+	err error
+}
+
+func readKeys(r *bufio.Reader, keys chan<- keyEvent) {
+	for {
+		key, err := readKey(r)
+		keys <- keyEvent{key, err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+type streamedBlock struct {
+	doc   *docLines
+	block render.Block
+	err   error
+}
 
-		if offset > max(0, len(lines)-pageSize) {
-			offset = max(0, len(lines)-pageSize)
+// readBlocks reads the blocks of the stream of d, as they arrive, until
+// it ends or done is closed.
+func readBlocks(d *docLines, blocks chan<- streamedBlock, done <-chan struct{}) {
+	for {
+		blk, err := d.stream.Next()
+		select {
+		case blocks <- streamedBlock{d, blk, err}:
+		case <-done:
+			d.stream.Close()
+			return
+		}
+		if err != nil {
+			return
 		}
 	}
 }
 
-func joinDocs(docs []render.Doc) string {
-	var b strings.Builder
-	for _, doc := range docs {
-		b.WriteString(doc.Body)
+// openKeyboard puts the terminal in raw mode and returns where to read keys
+// from. When stdin is not a terminal, because the document was piped in,
+// keys are read from the controlling terminal instead.
+func openKeyboard() (*os.File, func()) {
+	if restore, err := makeRaw(int(os.Stdin.Fd())); err == nil {
+		return os.Stdin, restore
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return os.Stdin, func() {}
+	}
+	restore, err := makeRaw(int(tty.Fd()))
+	if err != nil {
+		tty.Close()
+		return os.Stdin, func() {}
+	}
+	return tty, func() {
+		restore()
+		tty.Close()
+	}
+}
+
+func joinStatus(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
 	}
-	return b.String()
+	return strings.Join(kept, " | ")
 }
 
-func renderPage(out io.Writer, lines []string, offset, pageSize int, color bool, extra string, searching bool, searchInput string) {
-	// Always repaint the screen in pager mode to avoid drifting output.
-	fmt.Fprint(out, "\x1b[2J\x1b[H")
+// fileCommand runs :n, :p, ]f or [f, typed after the count n, from the
+// page at offset in file k. It returns the new offset, the file moved to
+// and a message for the status line.
+func fileCommand(buf *buffer, seq string, n, offset, k, pageSize int, v view) (int, int, string) {
+	last := len(buf.docs) - 1
+	switch seq {
+	case ":n", "]f":
+		if k == last {
+			return offset, k, "already at the last file"
+		}
+		k = min(k+max(n, 1), last)
+	case ":p", "[f":
+		switch {
+		case k == 0 && offset == 0:
+			return offset, k, "already at the first file"
+		case offset > buf.docs[k].start && n <= 1:
+			// Like less, :p first goes back to the top of the file.
+		default:
+			k = max(k-max(n, 1), 0)
+		}
+	default:
+		return offset, k, fmt.Sprintf("unknown command %s", seq)
+	}
+	offset = buf.fileStart(k)
+	buf.load(offset + pageSize)
+	return min(offset, lastOffset(buf, pageSize, v)), k, ""
+}
 
-	end := min(len(lines), offset+pageSize)
-	for i := offset; i < end; i++ {
-		fmt.Fprintln(out, lines[i])
+// renderPage returns the screen rows showing the page at offset, followed
+// by the status line and the search prompt, and the first line that the
+// page does not show in full. The status names file as the current one.
+func renderPage(buf *buffer, offset, file, pageSize int, v view, srch *search, extra string) ([]string, int) {
+	var rows []string
+	first, last := buf.pinned(offset)
+	for i := first; i < last; i++ {
+		rows = append(rows, v.rows(srch.highlight(i, buf.line(i)))...)
+	}
+	end := max(offset, last)
+	for ; end < buf.Len() && len(rows) < pageSize; end++ {
+		rows = append(rows, v.rows(srch.highlight(end, buf.line(end)))...)
+	}
+	next := end
+	if len(rows) > pageSize {
+		rows, next = rows[:pageSize], end-1
 	}
 
-	status := fmt.Sprintf("[%d-%d/%d] q quit | j/k or arrows | f/b/space page | / find | n/N next/prev", offset+1, end, len(lines))
+	// Messages go before the key help, which is cut first when the
+	// terminal is too narrow for the whole line.
+	status := fmt.Sprintf("[%s]", buf.position(offset, end))
+	help := "q quit | j/k or arrows | f/b/space page | Ng go to line | / find | n/N next/prev | w wrap"
+	if n := len(buf.docs); n > 1 {
+		status = fmt.Sprintf("%s (%d/%d) %s", buf.docs[file].title, file+1, n, status)
+		help += " | :n/:p file"
+	}
+	if !v.wrap {
+		help += " | h/l scroll"
+		if v.col > 0 {
+			status += fmt.Sprintf(" col %d", v.col+1)
+		}
+	}
 	if extra != "" {
 		status += " | " + extra
 	}
-	if color {
+	status += " | " + help
+	status = v.clip(status)
+	if v.color {
 		status = "\x1b[38;5;244m" + status + "\x1b[0m"
 	}
-	fmt.Fprintln(out, status)
+	rows = append(rows, status)
 
-	if searching {
-		prompt := "/" + searchInput
-		if color {
+	if srch != nil && srch.prompting {
+		prompt := "/" + srch.input
+		if srch.backward {
+			prompt = "?" + srch.input
+		}
+		switch {
+		case srch.fileOnly:
+			prompt += "   (this file, Tab for all files)"
+		case len(buf.docs) > 1:
+			prompt += "   (all files, Tab for this file)"
+		}
+		if v.color {
 			prompt = "\x1b[38;5;212m" + prompt + "\x1b[0m"
 		}
-		fmt.Fprintln(out, prompt)
+		rows = append(rows, prompt)
 	}
+	return rows, next
 }
 
 func readKey(r *bufio.Reader) (string, error) {
@@ -172,6 +470,10 @@ func readKey(r *bufio.Reader) (string, error) {
 		return "enter", nil
 	case 127, 8:
 		return "backspace", nil
+	case 3:
+		return "ctrl-c", nil
+	case '\t':
+		return "tab", nil
 	case 32:
 		return "space", nil
 	case 27:
@@ -191,6 +493,10 @@ func readKey(r *bufio.Reader) (string, error) {
 			return "up", nil
 		case 'B':
 			return "down", nil
+		case 'C':
+			return "right", nil
+		case 'D':
+			return "left", nil
 		case '5':
 			if r.Buffered() > 0 {
 				_, _ = r.ReadByte()
@@ -209,46 +515,54 @@ func readKey(r *bufio.Reader) (string, error) {
 	}
 }
 
-func terminalHeight() int {
-	if h, err := ttyHeight(int(os.Stdout.Fd())); err == nil && h > 0 {
-		return h
+// TerminalWidth reports the column count of the terminal behind f.
+func TerminalWidth(f *os.File) (int, bool) {
+	cols, _, err := ttySize(int(f.Fd()))
+	if err != nil || cols <= 0 {
+		return 0, false
 	}
-	height := 24
-	if h := strings.TrimSpace(os.Getenv("LINES")); h != "" {
-		if n, err := strconv.Atoi(h); err == nil && n > 5 {
-			height = n
-		}
-	}
-	return height
+	return cols, true
 }
 
-func ttyHeight(fd int) (int, error) {
-	ws := &winsize{}
-	_, _, errno := syscall.Syscall(
-		uintptr(syscall.SYS_IOCTL),
-		uintptr(fd),
-		uintptr(syscall.TIOCGWINSZ),
-		uintptr(unsafe.Pointer(ws)),
-	)
-	if errno != 0 {
-		return 0, errno
+func terminalSize() (width, height int) {
+	if w, h, err := ttySize(int(os.Stdout.Fd())); err == nil {
+		return w, h
 	}
-	if ws.Row == 0 {
-		return 0, fmt.Errorf("terminal row size is 0")
+	return envSize("COLUMNS", 80, 20), envSize("LINES", 24, 5)
+}
+
+func envSize(name string, fallback, floor int) int {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > floor {
+			return n
+		}
 	}
-	return int(ws.Row), nil
+	return fallback
 }
 
-type winsize struct {
-	Row    uint16
-	Col    uint16
-	Xpixel uint16
-	Ypixel uint16
+// restoreOnSignal puts the terminal back in cooked mode if the process is
+// terminated while the pager owns it. The returned func uninstalls the handler.
+func restoreOnSignal(restore func()) func() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			restore()
+			os.Exit(exitcode.Error)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
 }
 
-func computePageSize(height int, searching bool) int {
+func computePageSize(height int, prompting bool) int {
 	footerLines := 1
-	if searching {
+	if prompting {
 		footerLines++
 	}
 	pageSize := height - footerLines
@@ -258,72 +572,6 @@ func computePageSize(height int, searching bool) int {
 	return pageSize
 }
 
-func makeRaw(fd int) (func(), error) {
-	orig, err := getTermios(fd)
-	if err != nil {
-		return func() {}, err
-	}
-	raw := *orig
-	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
-	raw.Cflag |= syscall.CS8
-	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
-	raw.Cc[syscall.VMIN] = 1
-	raw.Cc[syscall.VTIME] = 0
-	if err := setTermios(fd, &raw); err != nil {
-		return func() {}, err
-	}
-	return func() {
-		_ = setTermios(fd, orig)
-	}, nil
-}
-
-func getTermios(fd int) (*syscall.Termios, error) {
-	termios := &syscall.Termios{}
-	_, _, errno := syscall.Syscall6(
-		uintptr(syscall.SYS_IOCTL),
-		uintptr(fd),
-		uintptr(syscall.TIOCGETA),
-		uintptr(unsafe.Pointer(termios)),
-		0,
-		0,
-		0,
-	)
-	if errno != 0 {
-		return nil, errno
-	}
-	return termios, nil
-}
-
-func setTermios(fd int, termios *syscall.Termios) error {
-	_, _, errno := syscall.Syscall6(
-		uintptr(syscall.SYS_IOCTL),
-		uintptr(fd),
-		uintptr(syscall.TIOCSETA),
-		uintptr(unsafe.Pointer(termios)),
-		0,
-		0,
-		0,
-	)
-	if errno != 0 {
-		return errno
-	}
-	return nil
-}
-
-func findMatches(lines []string, q string) []int {
-	if q == "" {
-		return nil
-	}
-	q = strings.ToLower(q)
-	matches := make([]int, 0)
-	for i, line := range lines {
-		if strings.Contains(strings.ToLower(line), q) {
-			matches = append(matches, i)
-		}
-	}
-	return matches
-}
-
 func min(a, b int) int {
 	if a < b {
 		return a
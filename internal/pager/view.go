@@ -0,0 +1,121 @@
+package pager
+
+import (
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+// hscrollStep is how many columns h and l scroll without a count.
+const hscrollStep = 8
+
+// view fits lines to the terminal width: wrapped onto as many rows as they
+// need, or cut to the columns starting at col, with a mark at each edge
+// that hides part of the line.
+type view struct {
+	width int // 0 leaves lines as they are
+	wrap  bool
+	col   int
+	color bool
+}
+
+// rows returns the screen rows that show line.
+func (v view) rows(line string) []string {
+	if v.width <= 0 {
+		return []string{line}
+	}
+	line = style.ExpandTabs(line, 8)
+	total := style.Width(line)
+	if v.wrap {
+		if total <= v.width {
+			return []string{line}
+		}
+		var rows []string
+		for from := 0; from < total; {
+			row, end := style.Slice(line, from, v.width)
+			rows = append(rows, row)
+			if end == from {
+				break // a rune wider than the terminal
+			}
+			from = end
+		}
+		return rows
+	}
+
+	from, avail := v.col, v.width
+	prefix, suffix := "", ""
+	if v.col > 0 && total > 0 {
+		prefix = v.mark("‹")
+		from++
+		avail--
+	}
+	if total > from+avail {
+		suffix = v.mark("›")
+		avail--
+	}
+	row, _ := style.Slice(line, from, avail)
+	return []string{prefix + row + suffix}
+}
+
+// clip cuts s to the width of the view, for the status line.
+func (v view) clip(s string) string {
+	if v.width <= 0 || style.Width(s) <= v.width {
+		return s
+	}
+	row, _ := style.Slice(s, 0, v.width)
+	return row
+}
+
+// reveal scrolls a view that cuts lines sideways so that m is in it.
+func (v view) reveal(line string, m match) view {
+	if v.wrap || v.width <= 0 {
+		return v
+	}
+	plain := style.Strip(line)
+	start := style.Width(style.ExpandTabs(plain[:min(m.start, len(plain))], 8))
+	if start < v.col || start >= v.col+v.width-2 {
+		v.col = max(0, start-v.width/4)
+	}
+	return v
+}
+
+func (v view) mark(s string) string {
+	if v.color {
+		return "\x1b[38;5;244m" + s + "\x1b[0m"
+	}
+	return s
+}
+
+// lastOffset is the first line of the page that ends with the last line.
+func lastOffset(buf *buffer, pageSize int, v view) int {
+	rows := 0
+	for i := buf.Len() - 1; i >= 0; i-- {
+		rows += len(v.rows(buf.line(i)))
+		if rows > pageSize {
+			return min(i+1, buf.Len()-1)
+		}
+	}
+	return 0
+}
+
+// pageUp is the first line of the page that ends right above offset and
+// has rows rows.
+func pageUp(buf *buffer, offset, rows int, v view) int {
+	used := 0
+	for i := offset - 1; i >= 0; i-- {
+		used += len(v.rows(buf.line(i)))
+		if used > rows {
+			return min(i+1, offset-1)
+		}
+	}
+	return 0
+}
+
+// pinnedRows is how many rows the lines pinned above the page at offset
+// take.
+func pinnedRows(buf *buffer, offset int, v view) int {
+	rows := 0
+	first, last := buf.pinned(offset)
+	for i := first; i < last; i++ {
+		rows += len(v.rows(buf.line(i)))
+	}
+	return rows
+}
@@ -0,0 +1,155 @@
+package pager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+	"github.com/rodrwan/prettycat/internal/render"
+)
+
+func TestViewWrapsLongLines(t *testing.T) {
+	v := view{width: 4, wrap: true}
+	got := v.rows("\x1b[31mabcdefghij\x1b[0m")
+	if len(got) != 3 {
+		t.Fatalf("rows = %q, want 3 rows", got)
+	}
+	for _, row := range got {
+		if !strings.HasPrefix(row, "\x1b[31m") {
+			t.Fatalf("row %q lost the color of the line", row)
+		}
+	}
+	if got := v.rows("\tx"); len(got) != 3 {
+		t.Fatalf("a tab should expand to 8 columns, got %q", got)
+	}
+}
+
+func TestViewCutsLinesWithMarks(t *testing.T) {
+	line := "0123456789abcdef"
+	tests := []struct {
+		col  int
+		want string
+	}{
+		{col: 0, want: "01234›"},
+		{col: 4, want: "‹5678›"},
+		{col: 12, want: "‹def"},
+		{col: 20, want: "‹"},
+	}
+	for _, tc := range tests {
+		v := view{width: 6, col: tc.col}
+		if got := v.rows(line); len(got) != 1 || got[0] != tc.want {
+			t.Errorf("col %d: rows = %q, want %q", tc.col, got, tc.want)
+		}
+	}
+	if got := (view{width: 6, col: 4}).rows(""); got[0] != "" {
+		t.Fatalf("an empty line should stay empty, got %q", got)
+	}
+}
+
+func TestLastOffsetCountsWrappedRows(t *testing.T) {
+	buf := newBuffer([]render.Doc{{Body: "a\nb\n" + strings.Repeat("x", 10) + "\n"}})
+	if got := lastOffset(buf, 3, view{width: 4, wrap: true}); got != 2 {
+		t.Fatalf("lastOffset with wrapping = %d, want 2", got)
+	}
+	if got := lastOffset(buf, 3, view{width: 4}); got != 0 {
+		t.Fatalf("lastOffset without wrapping = %d, want 0", got)
+	}
+}
+
+func TestPagingShowsEveryWrappedRow(t *testing.T) {
+	var body strings.Builder
+	for i := 0; i < 12; i++ {
+		body.WriteString(fmt.Sprintf("%02d %s\n", i, strings.Repeat("x", 10)))
+	}
+	buf := newBuffer([]render.Doc{{Body: body.String()}})
+	v := view{width: 8, wrap: true} // every line takes two rows
+
+	offset, seen := 0, map[int]bool{}
+	for pages := 0; offset < buf.Len(); pages++ {
+		if pages > 20 {
+			t.Fatal("paging does not reach the end")
+		}
+		rows, next := renderPage(buf, offset, 0, 5, v, nil, "")
+		for _, row := range rows[:len(rows)-1] {
+			if n, err := strconv.Atoi(row[:2]); err == nil {
+				seen[n] = true
+			}
+		}
+		if next <= offset {
+			t.Fatalf("page at %d does not move forward", offset)
+		}
+		offset = next
+	}
+	for i := 0; i < 12; i++ {
+		if !seen[i] {
+			t.Fatalf("line %d was never shown", i)
+		}
+	}
+
+	if got := pageUp(buf, 6, 5, v); got != 4 {
+		t.Fatalf("pageUp(6) = %d, want 4", got)
+	}
+	if got := pageUp(buf, 1, 5, v); got != 0 {
+		t.Fatalf("pageUp(1) = %d, want 0", got)
+	}
+}
+
+func TestPagingKeepsRowsUnderThePinnedHeader(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("id,name\n")
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&csv, "%d,row%d\n", i, i)
+	}
+	doc, err := render.Render(input.Source{Name: "t.csv", Data: []byte(csv.String())}, render.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Sticky == 0 {
+		t.Fatal("expected a pinned CSV header")
+	}
+	buf := newBuffer([]render.Doc{doc})
+	v := view{width: 80, wrap: true}
+	const pageSize = 6
+
+	shown := func(rows []string) map[int]bool {
+		seen := map[int]bool{}
+		for _, row := range rows {
+			if _, after, ok := strings.Cut(row, "row"); ok {
+				if n, err := strconv.Atoi(strings.TrimSpace(after)); err == nil {
+					seen[n] = true
+				}
+			}
+		}
+		return seen
+	}
+
+	var offsets []int
+	offset, seen := 0, map[int]bool{}
+	for offset < buf.Len()-1 {
+		offsets = append(offsets, offset)
+		rows, next := renderPage(buf, offset, 0, pageSize, v, nil, "")
+		for n := range shown(rows) {
+			seen[n] = true
+		}
+		offset = max(next, offset+1)
+	}
+	for i := 1; i <= 30; i++ {
+		if !seen[i] {
+			t.Fatalf("row %d was never shown", i)
+		}
+	}
+
+	// Paging back from a page shows the rows right above it.
+	at := offsets[3]
+	rows, _ := renderPage(buf, at, 0, pageSize, v, nil, "")
+	top := 31
+	for n := range shown(rows) {
+		top = min(top, n)
+	}
+	back, _ := renderPage(buf, pageUp(buf, at, pageSize-pinnedRows(buf, at, v), v), 0, pageSize, v, nil, "")
+	if !shown(back)[top-1] {
+		t.Fatalf("page up from row %d skipped row %d: %q", top, top-1, back)
+	}
+}
@@ -0,0 +1,80 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	hexOffset     = "\x1b[38;5;244m"
+	hexNull       = "\x1b[38;5;240m"
+	hexPrintable  = "\x1b[38;5;117m"
+	hexWhitespace = "\x1b[38;5;114m"
+	hexControl    = "\x1b[38;5;179m"
+	hexHigh       = "\x1b[38;5;209m"
+)
+
+// renderHex formats data like `xxd`: an offset, the bytes in groups of two,
+// and their printable ASCII form. Rows hold 16 bytes, or 8 when opts.Width
+// is too narrow for that.
+func renderHex(data []byte, opts Options) string {
+	perRow := 16
+	if opts.Width > 0 && opts.Width < 68 {
+		perRow = 8
+	}
+	if len(data) == 0 {
+		return "\n"
+	}
+
+	var b strings.Builder
+	for off := 0; off < len(data); off += perRow {
+		row := data[off:min(off+perRow, len(data))]
+		offset := fmt.Sprintf("%08x:", off)
+		if opts.Color {
+			offset = hexOffset + offset + ansiReset
+		}
+		b.WriteString(offset)
+
+		for i := 0; i < perRow; i++ {
+			if i%2 == 0 {
+				b.WriteByte(' ')
+			}
+			if i >= len(row) {
+				b.WriteString("  ")
+				continue
+			}
+			b.WriteString(paintByte(row[i], fmt.Sprintf("%02x", row[i]), opts.Color))
+		}
+
+		b.WriteString("  ")
+		for _, c := range row {
+			ch := "."
+			if c >= 0x20 && c < 0x7f {
+				ch = string(rune(c))
+			}
+			b.WriteString(paintByte(c, ch, opts.Color))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// paintByte colors s by the class of byte c, so the hex and ASCII columns
+// of a byte share a color.
+func paintByte(c byte, s string, color bool) string {
+	if !color {
+		return s
+	}
+	style := hexControl
+	switch {
+	case c == 0:
+		style = hexNull
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		style = hexWhitespace
+	case c > 0x20 && c < 0x7f:
+		style = hexPrintable
+	case c >= 0x80:
+		style = hexHigh
+	}
+	return style + s + ansiReset
+}
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
+
+func TestRenderHexLayout(t *testing.T) {
+	data := []byte("\x7fELF\x02\x01\x01\x00hello, world!\n")
+	want := "00000000: 7f45 4c46 0201 0100 6865 6c6c 6f2c 2077  .ELF....hello, w\n" +
+		"00000010: 6f72 6c64 210a                           orld!.\n"
+	if got := renderHex(data, Options{}); got != want {
+		t.Fatalf("renderHex mismatch:\n got %q\nwant %q", got, want)
+	}
+	narrow := renderHex(data, Options{Width: 60})
+	if !strings.HasPrefix(narrow, "00000000: 7f45 4c46 0201 0100  .ELF....\n00000008: ") {
+		t.Fatalf("expected 8 bytes per row, got %q", narrow)
+	}
+}
+
+func TestRenderBinaryModes(t *testing.T) {
+	src := input.Source{Name: "a.out", Data: []byte("\x00\x01text")}
+	doc, err := Render(src, Options{Number: NumberAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Kind != KindBinary || !strings.HasPrefix(doc.Body, "00000000: 0001") || doc.Numbers != nil {
+		t.Fatalf("hex doc = %+v", doc)
+	}
+	doc, err = Render(src, Options{Binary: BinaryRaw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Kind == KindBinary || doc.Body != "\x00\x01text\n" {
+		t.Fatalf("raw doc = %+v", doc)
+	}
+}
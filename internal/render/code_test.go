@@ -4,13 +4,17 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+	"github.com/rodrwan/prettycat/internal/style"
 )
 
 func TestRenderCodeNoColorHasNoANSI(t *testing.T) {
-	out, err := renderCode("main.go", []byte("package main\n\nfunc main() {}\n"), Options{Color: false})
+	doc, err := Render(input.Source{Name: "main.go", Data: []byte("package main\n\nfunc main() {}\n")}, Options{Color: false})
 	if err != nil {
-		t.Fatalf("renderCode returned error: %v", err)
+		t.Fatalf("Render returned error: %v", err)
 	}
+	out := doc.Body
 
 	ansi := regexp.MustCompile(`\x1b\[[0-9;]*m`)
 	if ansi.MatchString(out) {
@@ -19,10 +23,11 @@ func TestRenderCodeNoColorHasNoANSI(t *testing.T) {
 }
 
 func TestRenderCodeColorUsesRealANSIBytes(t *testing.T) {
-	out, err := renderCode("main.go", []byte("package main\nfunc main() {}\n"), Options{Color: true})
+	doc, err := Render(input.Source{Name: "main.go", Data: []byte("package main\nfunc main() {}\n")}, Options{Color: true})
 	if err != nil {
-		t.Fatalf("renderCode returned error: %v", err)
+		t.Fatalf("Render returned error: %v", err)
 	}
+	out := doc.Body
 	if strings.Contains(out, "\\x1b[") {
 		t.Fatalf("expected real ANSI escape bytes, got literal escape text: %q", out)
 	}
@@ -30,3 +35,41 @@ func TestRenderCodeColorUsesRealANSIBytes(t *testing.T) {
 		t.Fatalf("expected ANSI escape bytes in color output, got %q", out)
 	}
 }
+
+func TestRenderWrapsCodeOnlyInAlwaysMode(t *testing.T) {
+	src := input.Source{Name: "main.go", Data: []byte("\tfmt.Println(\"hello\", \"world\")\n")}
+	doc, err := Render(src, Options{Width: 20})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if strings.Count(doc.Body, "\n") != 1 {
+		t.Fatalf("expected code to stay unwrapped, got %q", doc.Body)
+	}
+
+	doc, err = Render(src, Options{Width: 20, Wrap: WrapAlways})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	want := "        fmt.Println(\n\"hello\", \"world\")\n"
+	if doc.Body != want {
+		t.Fatalf("Render = %q, want %q", doc.Body, want)
+	}
+}
+
+func TestRenderWrapsTabIndentedCodeToTheWidth(t *testing.T) {
+	src := input.Source{Name: "main.go", Data: []byte("func f() {\n\tif ok {\n\t\treturn someFunction(argumentOne, argumentTwo)\n\t}\n}\n")}
+	for _, number := range []NumberMode{NumberNone, NumberAll} {
+		doc, err := Render(src, Options{Width: 30, Wrap: WrapAlways, Number: number, Color: true})
+		if err != nil {
+			t.Fatalf("Render returned error: %v", err)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(doc.Body, "\n"), "\n") {
+			if strings.Contains(line, "\t") || style.Width(line) > 30 {
+				t.Fatalf("number %q: line %q is wider than 30 columns", number, line)
+			}
+		}
+		if !strings.Contains(style.Strip(doc.Body), "        if ok {") {
+			t.Fatalf("number %q: tabs should expand to 8 columns, got %q", number, doc.Body)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rodrwan/prettycat/internal/markdown"
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+const (
+	csvGrid   = "\x1b[38;5;240m"
+	csvHeader = "\x1b[1;38;5;117m"
+	csvNumber = "\x1b[38;5;141m"
+)
+
+// csvDelimiters are the candidates tried when sniffing a delimiter.
+var csvDelimiters = []rune{',', '\t', ';', '|'}
+
+// csvHeaderLines is how many lines of a rendered table form its header: the
+// header row and the rule below it.
+const csvHeaderLines = 2
+
+// renderCSV lays delimited data out as a table with the first row as a
+// header. Columns are aligned by display width, numeric columns to the
+// right, and shrunk with an ellipsis when the table is wider than
+// opts.Width. Data that does not parse is returned as plain text.
+func renderCSV(name string, in []byte, opts Options) (string, []string) {
+	delim := sniffDelimiter(name, in)
+	rows, err := readCSV(in, delim)
+	if err != nil {
+		return renderPlain(in), []string{fmt.Sprintf("%s: %v", name, err)}
+	}
+	if len(rows) == 0 {
+		return renderPlain(in), nil
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	widths := make([]int, cols)
+	numeric := make([]bool, cols)
+	for i := range numeric {
+		numeric[i] = len(rows) > 1
+	}
+	for r, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], style.Width(cell))
+			if r > 0 && cell != "" && !isCSVNumber(cell) {
+				numeric[i] = false
+			}
+		}
+	}
+	if opts.Width > 0 {
+		// fitColumns budgets for an outer border that tables here do not
+		// draw.
+		fitColumns(widths, opts.Width+4)
+	}
+
+	sep, rule, cross := " | ", "-", "-+-"
+	if opts.Color {
+		sep, rule, cross = csvGrid+" │ "+ansiReset, "─", "─┼─"
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		cells := make([]string, cols)
+		for i := range cells {
+			cell := ""
+			if i < len(row) {
+				cell = truncateCell(row[i], widths[i])
+			}
+			align := markdown.AlignLeft
+			if numeric[i] && r > 0 {
+				align = markdown.AlignRight
+			}
+			cell = alignCell(cell, widths[i], align)
+			switch {
+			case !opts.Color:
+			case r == 0:
+				cell = csvHeader + cell + ansiReset
+			case numeric[i]:
+				cell = csvNumber + cell + ansiReset
+			}
+			cells[i] = cell
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, sep), " "))
+		b.WriteByte('\n')
+
+		if r == 0 {
+			parts := make([]string, cols)
+			for i, w := range widths {
+				parts[i] = strings.Repeat(rule, w)
+			}
+			line := strings.Join(parts, cross)
+			if opts.Color {
+				line = csvGrid + line + ansiReset
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func readCSV(in []byte, delim rune) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(in))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = delim == '\t'
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		for i, cell := range rec {
+			rec[i] = strings.ReplaceAll(strings.ReplaceAll(cell, "\r\n", "\n"), "\n", "↵")
+		}
+	}
+	return records, nil
+}
+
+// sniffDelimiter picks tabs for .tsv files, and otherwise the candidate
+// that splits the first lines into the most fields consistently.
+func sniffDelimiter(name string, in []byte) rune {
+	if strings.HasSuffix(strings.ToLower(name), ".tsv") {
+		return '\t'
+	}
+	sample := in
+	if lines := bytes.SplitN(in, []byte("\n"), 11); len(lines) == 11 {
+		sample = bytes.Join(lines[:10], []byte("\n"))
+	}
+
+	best, bestFields := ',', 1
+	for _, d := range csvDelimiters {
+		r := csv.NewReader(bytes.NewReader(sample))
+		r.Comma = d
+		r.FieldsPerRecord = 0
+		records, err := r.ReadAll()
+		if err != nil || len(records) == 0 {
+			continue
+		}
+		if n := len(records[0]); n > bestFields {
+			best, bestFields = d, n
+		}
+	}
+	return best
+}
+
+// truncateCell shortens s to width display columns, ending with an
+// ellipsis when something was cut.
+func truncateCell(s string, width int) string {
+	if style.Width(s) <= width {
+		return s
+	}
+	w := 0
+	for i, r := range s {
+		rw := style.RuneWidth(r)
+		if w+rw > width-1 {
+			return s[:i] + "…"
+		}
+		w += rw
+	}
+	return s
+}
+
+func isCSVNumber(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.IndexByte("+-.0123456789", s[0]) < 0 {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
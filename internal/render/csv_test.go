@@ -0,0 +1,69 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
+
+func TestRenderCSVAlignsColumns(t *testing.T) {
+	in := "name,qty,note\napple,3,\"red, sweet\"\n日本,12,\"two\nlines\"\n"
+	out, warnings := renderCSV("a.csv", []byte(in), Options{})
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %q", warnings)
+	}
+	want := "name  | qty | note\n" +
+		"------+-----+-----------\n" +
+		"apple |   3 | red, sweet\n" +
+		"日本  |  12 | two↵lines\n"
+	if out != want {
+		t.Fatalf("renderCSV mismatch:\n got %q\nwant %q", out, want)
+	}
+}
+
+func TestRenderCSVSniffsDelimiter(t *testing.T) {
+	out, _ := renderCSV("data", []byte("a;b\n1;2\n"), Options{})
+	if !strings.HasPrefix(out, "a | b\n") {
+		t.Fatalf("expected ';' to be detected, got %q", out)
+	}
+	out, _ = renderCSV("data.tsv", []byte("a,b\tc\n1\t2\n"), Options{})
+	if !strings.HasPrefix(out, "a,b | c\n") {
+		t.Fatalf("expected tabs for .tsv, got %q", out)
+	}
+}
+
+func TestRenderCSVTruncatesToWidth(t *testing.T) {
+	in := "id,description\n1,a description that is far too long\n"
+	out, _ := renderCSV("a.csv", []byte(in), Options{Width: 20})
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if len([]rune(line)) > 20 {
+			t.Fatalf("line %q exceeds width", line)
+		}
+	}
+	if !strings.Contains(out, "…") {
+		t.Fatalf("expected an ellipsis, got %q", out)
+	}
+}
+
+func TestRenderCSVInvalidFallsBack(t *testing.T) {
+	in := "a,b\n\"unterminated,1\n"
+	out, warnings := renderCSV("bad.csv", []byte(in), Options{})
+	if out != in || len(warnings) != 1 || !strings.HasPrefix(warnings[0], "bad.csv: ") {
+		t.Fatalf("expected raw output and a warning, got %q %q", out, warnings)
+	}
+}
+
+func TestRenderCSVPinsHeader(t *testing.T) {
+	doc, err := Render(input.Source{Name: "a.csv", Data: []byte("a\n1\n")}, Options{})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if doc.Kind != KindCSV || doc.Sticky != 2 || doc.StickyAt != 0 {
+		t.Fatalf("doc = %+v, want a csv doc with a 2-line sticky header", doc)
+	}
+	doc.Prepend("==> a.csv <==\n")
+	if doc.StickyAt != 1 {
+		t.Fatalf("StickyAt = %d after Prepend, want 1", doc.StickyAt)
+	}
+}
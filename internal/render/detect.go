@@ -1,7 +1,11 @@
 package render
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -12,13 +16,271 @@ var markdownExt = map[string]struct{}{
 	".mkd":      {},
 }
 
+// Detection is the result of sniffing a source: how to render it and, for
+// code, which language to highlight it as.
+type Detection struct {
+	Kind     Kind
+	Language string
+}
+
+// filenameLanguages maps well-known file names (lowercased) to languages.
+var filenameLanguages = map[string]string{
+	"makefile":      "make",
+	"gnumakefile":   "make",
+	"dockerfile":    "dockerfile",
+	"containerfile": "dockerfile",
+	"go.mod":        "gomod",
+	"go.work":       "gomod",
+	".bashrc":       "shell",
+	".bash_profile": "shell",
+	".bash_logout":  "shell",
+	".bash_aliases": "shell",
+	".profile":      "shell",
+	".zshrc":        "shell",
+	".zshenv":       "shell",
+	".zprofile":     "shell",
+	"gemfile":       "ruby",
+	"rakefile":      "ruby",
+	"vagrantfile":   "ruby",
+}
+
+// interpreters maps shebang interpreters, without version suffix, to
+// languages when the name is not already a language alias.
+var interpreters = map[string]string{
+	"bun":     "javascript",
+	"deno":    "typescript",
+	"ts-node": "typescript",
+	"pypy":    "python",
+	"irb":     "ruby",
+}
+
+var (
+	reVimModeline   = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype|syntax)=([\w+.-]+)`)
+	reEmacsModeline = regexp.MustCompile(`-\*-(.*?)-\*-`)
+
+	reGoPackage     = regexp.MustCompile(`(?m)^package [a-z_]\w*\s*$`)
+	reGoDecl        = regexp.MustCompile(`(?m)^(?:func|import|type|var|const) `)
+	rePythonDef     = regexp.MustCompile(`(?m)^(?:def|class) \w+.*:\s*$`)
+	rePythonImport  = regexp.MustCompile(`(?m)^(?:from [\w.]+ import |import \w+(?:\.\w+)*\s*$)`)
+	reCInclude      = regexp.MustCompile(`(?m)^#include [<"]`)
+	reMarkdownHead  = regexp.MustCompile(`(?m)^#{1,6} \S`)
+	reMarkdownOther = regexp.MustCompile("(?m)^(?:\\s*[-*+] \\S|\\s*\\d+\\. \\S|```|> )|\\[[^\\]]+\\]\\([^)]+\\)")
+)
+
+// sniffLimit bounds how much content the heuristics look at.
+const sniffLimit = 64 << 10
+
 func DetectKind(name string) Kind {
+	return Detect(name, nil).Kind
+}
+
+// Detect picks a renderer for a source by checking, in order, well-known
+// file names, the extension, a shebang line, Vim/Emacs modelines and
+// finally content heuristics.
+func Detect(name string, data []byte) Detection {
+	base := strings.ToLower(filepath.Base(name))
+	if id, ok := filenameLanguages[base]; ok {
+		return detection(id)
+	}
+	if strings.HasPrefix(base, "dockerfile.") || strings.HasSuffix(base, ".dockerfile") {
+		return detection("dockerfile")
+	}
+
 	ext := strings.ToLower(filepath.Ext(name))
 	if _, ok := markdownExt[ext]; ok {
-		return KindMarkdown
+		return Detection{Kind: KindMarkdown, Language: "markdown"}
+	}
+	if lang, ok := LanguageForName(name); ok {
+		return detection(lang.ID)
+	}
+
+	if len(data) > sniffLimit {
+		data = data[:sniffLimit]
 	}
+	for _, sniff := range []func([]byte) string{detectShebang, detectModeline, detectContent} {
+		if id := sniff(data); id != "" {
+			if d := detection(id); d.Kind != KindPlain {
+				return d
+			}
+		}
+	}
+
 	if ext == "" {
-		return KindPlain
+		return Detection{Kind: KindPlain}
+	}
+	return Detection{Kind: KindCode}
+}
+
+// detection resolves a language ID or alias; unknown IDs render as plain.
+func detection(id string) Detection {
+	d, _ := LookupLanguage(id)
+	return d
+}
+
+// LookupLanguage resolves a language ID or alias as accepted by --language,
+// including "markdown" and "plain".
+func LookupLanguage(id string) (Detection, bool) {
+	switch strings.ToLower(strings.TrimSpace(id)) {
+	case "markdown", "md", "gfm":
+		return Detection{Kind: KindMarkdown, Language: "markdown"}, true
+	case "plain", "text", "txt":
+		return Detection{Kind: KindPlain}, true
+	}
+	if lang, ok := LanguageByID(id); ok {
+		switch lang.ID {
+		case "json":
+			return Detection{Kind: KindJSON, Language: lang.ID}, true
+		case "jsonl":
+			return Detection{Kind: KindLog, Language: lang.ID}, true
+		case "yaml":
+			return Detection{Kind: KindYAML, Language: lang.ID}, true
+		case "csv":
+			return Detection{Kind: KindCSV, Language: lang.ID}, true
+		case "diff":
+			return Detection{Kind: KindDiff, Language: lang.ID}, true
+		}
+		return Detection{Kind: KindCode, Language: lang.ID}, true
+	}
+	return Detection{Kind: KindPlain}, false
+}
+
+func detectShebang(data []byte) string {
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return ""
+	}
+	fields := strings.Fields(firstLine(data)[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = filepath.Base(f)
+				break
+			}
+		}
+	}
+	interp = strings.TrimRight(interp, "0123456789.")
+	if id, ok := interpreters[interp]; ok {
+		return id
+	}
+	return interp
+}
+
+// detectModeline looks for a Vim modeline in the first or last five lines
+// and an Emacs mode line in the first two.
+func detectModeline(data []byte) string {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if i >= 5 && i < len(lines)-5 {
+			continue
+		}
+		if m := reVimModeline.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	for _, line := range lines[:min(len(lines), 2)] {
+		if m := reEmacsModeline.FindStringSubmatch(line); m != nil {
+			return emacsMode(m[1])
+		}
+	}
+	return ""
+}
+
+func emacsMode(vars string) string {
+	if !strings.Contains(vars, ":") {
+		return strings.TrimSuffix(strings.TrimSpace(vars), "-mode")
+	}
+	for _, v := range strings.Split(vars, ";") {
+		key, value, ok := strings.Cut(v, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "mode") {
+			return strings.TrimSuffix(strings.TrimSpace(value), "-mode")
+		}
+	}
+	return ""
+}
+
+func detectContent(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && jsonPrefix(trimmed) {
+		return "json"
+	}
+	if looksLikeJSONLines(trimmed) {
+		return "jsonl"
+	}
+	if looksLikeDiff(data) {
+		return "diff"
+	}
+	s := string(data)
+	switch {
+	case reGoPackage.MatchString(s) && reGoDecl.MatchString(s):
+		return "go"
+	case reCInclude.MatchString(s):
+		return "c"
+	case rePythonDef.MatchString(s) && rePythonImport.MatchString(s):
+		return "python"
+	case reMarkdownHead.MatchString(s) && reMarkdownOther.MatchString(s):
+		return "markdown"
+	}
+	return ""
+}
+
+// jsonPrefix reports whether data is one JSON value, or the beginning of
+// one: the first block of a large or slowly piped document is cut short.
+func jsonPrefix(data []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			return true
+		case err != nil:
+			return false
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			_, err := dec.Token()
+			return err == io.EOF
+		}
+	}
+}
+
+// looksLikeJSONLines reports whether most lines, including the first, are
+// JSON objects. Other lines are tolerated so logs mixed with plain output
+// still qualify.
+func looksLikeJSONLines(data []byte) bool {
+	records, other := 0, 0
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '{' && json.Valid(line) {
+			records++
+		} else if i == 0 {
+			return false
+		} else {
+			other++
+		}
+	}
+	return records >= 2 && records > other
+}
+
+func firstLine(data []byte) string {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[:i]
 	}
-	return KindCode
+	return strings.TrimRight(string(data), "\r")
 }
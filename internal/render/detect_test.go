@@ -1,6 +1,10 @@
 package render
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
 
 func TestDetectKind(t *testing.T) {
 	tests := []struct {
@@ -22,3 +26,58 @@ func TestDetectKind(t *testing.T) {
 		})
 	}
 }
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		want Detection
+	}{
+		{name: "makefile", file: "src/Makefile", want: Detection{KindCode, "make"}},
+		{name: "dockerfile variant", file: "Dockerfile.dev", want: Detection{KindCode, "dockerfile"}},
+		{name: "dotfile", file: "/home/me/.bashrc", want: Detection{KindCode, "shell"}},
+		{name: "go.mod", file: "go.mod", want: Detection{KindCode, "gomod"}},
+		{name: "extension", file: "lib.rs", want: Detection{KindCode, "rust"}},
+		{name: "env shebang", file: "tool", data: "#!/usr/bin/env -S python3.12 -u\nprint(1)\n", want: Detection{KindCode, "python"}},
+		{name: "direct shebang", file: "stdin", data: "#!/bin/bash\necho hi\n", want: Detection{KindCode, "shell"}},
+		{name: "node shebang", file: "cli", data: "#!/usr/bin/env node\n", want: Detection{KindCode, "javascript"}},
+		{name: "vim modeline", file: "stdin", data: "x = 1\n# vim: set ts=4 ft=ruby:\n", want: Detection{KindCode, "ruby"}},
+		{name: "emacs modeline", file: "notes", data: "/* -*- mode: c++; tab-width: 4 -*- */\nint x;\n", want: Detection{KindCode, "cpp"}},
+		{name: "emacs short form", file: "build", data: "# -*- makefile -*-\nall:\n", want: Detection{KindCode, "make"}},
+		{name: "json content", file: "stdin", data: `{"a": [1, 2]}`, want: Detection{KindJSON, "json"}},
+		{name: "start of json", file: "stdin", data: "{\n  \"a\": [1,\n", want: Detection{KindJSON, "json"}},
+		{name: "bracketed text", file: "stdin", data: "[INFO] started\n", want: Detection{KindPlain, ""}},
+		{name: "go content", file: "stdin", data: "package main\n\nfunc main() {}\n", want: Detection{KindCode, "go"}},
+		{name: "markdown content", file: "stdin", data: "# Title\n\n- item\n", want: Detection{KindMarkdown, "markdown"}},
+		{name: "plain content", file: "stdin", data: "just some words\n", want: Detection{KindPlain, ""}},
+		{name: "unknown extension", file: "notes.txt", data: "hello\n", want: Detection{KindCode, ""}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Detect(tc.file, []byte(tc.data)); got != tc.want {
+				t.Fatalf("Detect(%q) = %+v, want %+v", tc.file, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderLanguageOverridesDetection(t *testing.T) {
+	src := input.Source{Name: "stdin", Data: []byte("# not a heading\n")}
+	doc, err := Render(src, Options{Language: "sh"})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if doc.Kind != KindCode || doc.Language != "shell" {
+		t.Fatalf("Render kind/language = %q/%q, want code/shell", doc.Kind, doc.Language)
+	}
+
+	doc, err = Render(input.Source{Name: "README.md", Data: []byte("# x\n")}, Options{Language: "plain"})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if doc.Kind != KindPlain || doc.Body != "# x\n" {
+		t.Fatalf("expected forced plain output, got %q (%q)", doc.Body, doc.Kind)
+	}
+}
@@ -0,0 +1,405 @@
+package render
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+const (
+	diffFile      = "\x1b[1;38;5;117m"
+	diffMeta      = "\x1b[38;5;244m"
+	diffHunk      = "\x1b[38;5;141m"
+	diffDelMark   = "\x1b[38;5;203m"
+	diffAddMark   = "\x1b[38;5;114m"
+	diffDelBg     = "\x1b[48;5;52m"
+	diffAddBg     = "\x1b[48;5;22m"
+	diffDelWordBg = "\x1b[48;5;88m"
+	diffAddWordBg = "\x1b[48;5;28m"
+	diffGutter    = "\x1b[38;5;240m"
+)
+
+// defaultSideBySideWidth is used when the output width is unknown.
+const defaultSideBySideWidth = 160
+
+// maxWordDiffTokens bounds the quadratic word diff on very long lines.
+const maxWordDiffTokens = 400
+
+var reHunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+var diffHeaderPrefixes = []string{
+	"diff ", "index ", "--- ", "+++ ", "new file mode", "deleted file mode", "old mode", "new mode",
+	"similarity index", "dissimilarity index", "rename from", "rename to", "copy from", "copy to",
+	"Binary files",
+}
+
+// diffLine is one side of a changed line: its text and the byte ranges that
+// differ from its counterpart.
+type diffLine struct {
+	no      int
+	text    string
+	changed [][2]int
+}
+
+type diffRenderer struct {
+	opts    Options
+	lang    *Language
+	width   int
+	out     []string
+	oldNo   int
+	newNo   int
+	oldLeft int
+	newLeft int
+	dels    []diffLine
+	adds    []diffLine
+}
+
+// renderDiff colors a unified diff. Paired removed and added lines get
+// their changed words highlighted, and hunk content is highlighted as the
+// language of the file named in the headers.
+func renderDiff(in []byte, opts Options) string {
+	if !opts.Color && !opts.SideBySide {
+		return renderPlain(in)
+	}
+	r := &diffRenderer{opts: opts, lang: genericLanguage, width: opts.Width}
+	if r.width <= 0 {
+		r.width = defaultSideBySideWidth
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(in), "\n"), "\n") {
+		r.line(strings.TrimRight(line, "\r"))
+	}
+	r.flush()
+	return strings.Join(r.out, "\n") + "\n"
+}
+
+func (r *diffRenderer) paint(style, s string) string {
+	if !r.opts.Color || s == "" {
+		return s
+	}
+	return style + s + ansiReset
+}
+
+func (r *diffRenderer) line(line string) {
+	inHunk := r.oldLeft > 0 || r.newLeft > 0
+	switch {
+	case inHunk && strings.HasPrefix(line, "-"):
+		r.dels = append(r.dels, diffLine{no: r.oldNo, text: line[1:]})
+		r.oldNo++
+		r.oldLeft--
+		return
+	case inHunk && strings.HasPrefix(line, "+"):
+		r.adds = append(r.adds, diffLine{no: r.newNo, text: line[1:]})
+		r.newNo++
+		r.newLeft--
+		return
+	}
+
+	r.flush()
+	switch {
+	case inHunk && (strings.HasPrefix(line, " ") || line == ""):
+		text := strings.TrimPrefix(line, " ")
+		r.context(text)
+		r.oldNo++
+		r.newNo++
+		r.oldLeft--
+		r.newLeft--
+	case strings.HasPrefix(line, `\`):
+		r.out = append(r.out, r.paint(diffMeta, line))
+	case strings.HasPrefix(line, "@@"):
+		r.hunk(line)
+	case isDiffHeader(line):
+		r.header(line)
+	default:
+		r.out = append(r.out, line)
+	}
+}
+
+func isDiffHeader(line string) bool {
+	for _, p := range diffHeaderPrefixes {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *diffRenderer) header(line string) {
+	switch {
+	case strings.HasPrefix(line, "diff "):
+		r.lang = genericLanguage
+		r.out = append(r.out, r.paint(diffFile, line))
+	case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+		if lang, ok := diffFileLanguage(line[4:]); ok {
+			r.lang = lang
+		}
+		r.out = append(r.out, r.paint(diffFile, line))
+	default:
+		r.out = append(r.out, r.paint(diffMeta, line))
+	}
+}
+
+// diffFileLanguage detects the language of a path from a ---/+++ header.
+func diffFileLanguage(path string) (*Language, bool) {
+	if i := strings.IndexByte(path, '\t'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "/dev/null" {
+		return nil, false
+	}
+	for _, prefix := range []string{"a/", "b/"} {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	return LanguageByID(Detect(path, nil).Language)
+}
+
+func (r *diffRenderer) hunk(line string) {
+	m := reHunkHeader.FindStringSubmatch(line)
+	if m == nil {
+		r.out = append(r.out, r.paint(diffHunk, line))
+		return
+	}
+	r.oldNo, _ = strconv.Atoi(m[1])
+	r.newNo, _ = strconv.Atoi(m[3])
+	r.oldLeft, r.newLeft = 1, 1
+	if m[2] != "" {
+		r.oldLeft, _ = strconv.Atoi(m[2])
+	}
+	if m[4] != "" {
+		r.newLeft, _ = strconv.Atoi(m[4])
+	}
+	head := line[:len(line)-len(m[5])]
+	r.out = append(r.out, r.paint(diffHunk, head)+r.paint(diffMeta, m[5]))
+}
+
+func (r *diffRenderer) context(text string) {
+	if r.opts.SideBySide {
+		left := diffLine{no: r.oldNo, text: text}
+		right := diffLine{no: r.newNo, text: text}
+		r.out = append(r.out, r.row(&left, &right, ' ', ' '))
+		return
+	}
+	r.out = append(r.out, " "+r.code(text, nil, "", ""))
+}
+
+// flush emits the pending run of removed and added lines, pairing them in
+// order to compute word-level changes.
+func (r *diffRenderer) flush() {
+	if len(r.dels) == 0 && len(r.adds) == 0 {
+		return
+	}
+	for i := 0; i < min(len(r.dels), len(r.adds)); i++ {
+		r.dels[i].changed, r.adds[i].changed = wordDiff(r.dels[i].text, r.adds[i].text)
+	}
+
+	if r.opts.SideBySide {
+		for i := 0; i < max(len(r.dels), len(r.adds)); i++ {
+			var left, right *diffLine
+			if i < len(r.dels) {
+				left = &r.dels[i]
+			}
+			if i < len(r.adds) {
+				right = &r.adds[i]
+			}
+			r.out = append(r.out, r.row(left, right, '-', '+'))
+		}
+	} else {
+		for _, d := range r.dels {
+			r.out = append(r.out, r.changedLine('-', d))
+		}
+		for _, a := range r.adds {
+			r.out = append(r.out, r.changedLine('+', a))
+		}
+	}
+	r.dels, r.adds = r.dels[:0], r.adds[:0]
+}
+
+func (r *diffRenderer) changedLine(mark byte, l diffLine) string {
+	markStyle, bg, wordBg := diffDelMark, diffDelBg, diffDelWordBg
+	if mark == '+' {
+		markStyle, bg, wordBg = diffAddMark, diffAddBg, diffAddWordBg
+	}
+	if !r.opts.Color {
+		return string(mark) + l.text
+	}
+	return bg + markStyle + string(mark) + ansiReset + r.code(l.text, l.changed, bg, wordBg)
+}
+
+// row lays out one side-by-side row. A nil side is left blank.
+func (r *diffRenderer) row(left, right *diffLine, leftMark, rightMark byte) string {
+	numWidth := 4
+	// Each half is "nnnn m text"; the halves are joined by " │ ".
+	textWidth := max((r.width-3)/2-numWidth-3, 10)
+	half := func(l *diffLine, mark byte) string {
+		if l == nil {
+			return strings.Repeat(" ", numWidth+3+textWidth)
+		}
+		text, changed := clipDiffLine(l.text, l.changed, textWidth)
+		gutter := r.paint(diffGutter, padLeft(strconv.Itoa(l.no), numWidth)) + " "
+		pad := strings.Repeat(" ", textWidth-style.Width(style.ExpandTabs(text, 4)))
+		switch {
+		case mark == ' ' || !r.opts.Color:
+			return gutter + string(mark) + " " + r.code(text, nil, "", "") + pad
+		case mark == '-':
+			return gutter + diffDelBg + r.paint(diffDelMark, "- ") + r.code(text, changed, diffDelBg, diffDelWordBg) + diffDelBg + pad + ansiReset
+		default:
+			return gutter + diffAddBg + r.paint(diffAddMark, "+ ") + r.code(text, changed, diffAddBg, diffAddWordBg) + diffAddBg + pad + ansiReset
+		}
+	}
+	sep := " │ "
+	if !r.opts.Color {
+		sep = " | "
+	}
+	return strings.TrimRight(half(left, leftMark)+r.paint(diffGutter, sep)+half(right, rightMark), " ")
+}
+
+// code highlights text in the file's language on top of bg, switching to
+// wordBg inside the changed ranges.
+func (r *diffRenderer) code(text string, changed [][2]int, bg, wordBg string) string {
+	text = style.ExpandTabs(text, 4)
+	if !r.opts.Color {
+		return text
+	}
+	theme := defaultTheme
+	if r.lang == genericLanguage {
+		theme = genericTheme
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, tok := range Lex(r.lang, text) {
+		for len(tok.Text) > 0 {
+			background, n := bg, len(tok.Text)
+			for _, c := range changed {
+				switch {
+				case pos >= c[0] && pos < c[1]:
+					background, n = wordBg, min(n, c[1]-pos)
+				case c[0] > pos:
+					n = min(n, c[0]-pos)
+				}
+			}
+			if style := background + theme[tok.Class]; style != "" {
+				b.WriteString(style + tok.Text[:n] + ansiReset)
+			} else {
+				b.WriteString(tok.Text[:n])
+			}
+			tok.Text = tok.Text[n:]
+			pos += n
+		}
+	}
+	return b.String()
+}
+
+// clipDiffLine cuts text to width display columns, keeping change ranges
+// inside the kept part.
+func clipDiffLine(text string, changed [][2]int, width int) (string, [][2]int) {
+	text = style.ExpandTabs(text, 4)
+	if style.Width(text) <= width {
+		return text, changed
+	}
+	text = truncateCell(text, width)
+	limit := len(text) - len("…")
+	var kept [][2]int
+	for _, c := range changed {
+		if c[0] < limit {
+			kept = append(kept, [2]int{c[0], min(c[1], limit)})
+		}
+	}
+	return text, kept
+}
+
+// wordDiff returns the byte ranges of a and b, after tab expansion, that are
+// not part of their longest common subsequence of words.
+func wordDiff(a, b string) ([][2]int, [][2]int) {
+	ta, tb := diffTokens(style.ExpandTabs(a, 4)), diffTokens(style.ExpandTabs(b, 4))
+	if len(ta) > maxWordDiffTokens || len(tb) > maxWordDiffTokens {
+		return nil, nil
+	}
+
+	lcs := make([][]int, len(ta)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(tb)+1)
+	}
+	for i := len(ta) - 1; i >= 0; i-- {
+		for j := len(tb) - 1; j >= 0; j-- {
+			if ta[i] == tb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	keepA, keepB := make([]bool, len(ta)), make([]bool, len(tb))
+	for i, j := 0, 0; i < len(ta) && j < len(tb); {
+		switch {
+		case ta[i] == tb[j]:
+			keepA[i], keepB[j] = true, true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return changedRanges(ta, keepA), changedRanges(tb, keepB)
+}
+
+func changedRanges(tokens []string, keep []bool) [][2]int {
+	var ranges [][2]int
+	pos := 0
+	for i, tok := range tokens {
+		if !keep[i] {
+			if n := len(ranges); n > 0 && ranges[n-1][1] == pos {
+				ranges[n-1][1] += len(tok)
+			} else {
+				ranges = append(ranges, [2]int{pos, pos + len(tok)})
+			}
+		}
+		pos += len(tok)
+	}
+	return ranges
+}
+
+// diffTokens splits s into words, runs of spaces and single other runes.
+func diffTokens(s string) []string {
+	var tokens []string
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		n := size
+		switch {
+		case isWordRune(r):
+			for n < len(s) {
+				r2, s2 := utf8.DecodeRuneInString(s[n:])
+				if !isWordRune(r2) {
+					break
+				}
+				n += s2
+			}
+		case r == ' ':
+			for n < len(s) && s[n] == ' ' {
+				n++
+			}
+		}
+		tokens = append(tokens, s[:n])
+		s = s[n:]
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// looksLikeDiff reports whether data starts like a unified diff.
+func looksLikeDiff(data []byte) bool {
+	s := string(data)
+	if strings.HasPrefix(s, "diff --git ") {
+		return true
+	}
+	return strings.HasPrefix(s, "--- ") && strings.Contains(s, "\n+++ ") && strings.Contains(s, "\n@@ ")
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+const samplePatch = "diff --git a/main.go b/main.go\n" +
+	"index 1111111..2222222 100644\n" +
+	"--- a/main.go\n" +
+	"+++ b/main.go\n" +
+	"@@ -1,3 +1,3 @@\n" +
+	" package main\n" +
+	"-func f() { foo(a, b) }\n" +
+	"+func f() { foo(a, c) }\n" +
+	" // end\n"
+
+func TestWordDiffMarksChangedWords(t *testing.T) {
+	a, b := wordDiff("foo(a, b)", "foo(a, c)")
+	if len(a) != 1 || a[0] != [2]int{7, 8} {
+		t.Fatalf("old ranges = %v", a)
+	}
+	if len(b) != 1 || b[0] != [2]int{7, 8} {
+		t.Fatalf("new ranges = %v", b)
+	}
+}
+
+func TestRenderDiffColorsChanges(t *testing.T) {
+	out := renderDiff([]byte(samplePatch), Options{Color: true})
+	for _, want := range []string{diffFile + "diff --git", diffDelBg, diffAddBg, diffDelWordBg, diffAddWordBg} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if got := style.Strip(out); got != samplePatch {
+		t.Fatalf("stripped output changed the text:\n got %q\nwant %q", got, samplePatch)
+	}
+}
+
+func TestRenderDiffPlainPassesThrough(t *testing.T) {
+	if out := renderDiff([]byte(samplePatch), Options{}); out != samplePatch {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestRenderDiffSideBySide(t *testing.T) {
+	out := renderDiff([]byte(samplePatch), Options{SideBySide: true, Width: 80})
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	var rows []string
+	for _, line := range lines {
+		if style.Width(line) > 80 {
+			t.Fatalf("line %q exceeds width", line)
+		}
+		if strings.Contains(line, " | ") {
+			rows = append(rows, line)
+		}
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d:\n%s", len(rows), out)
+	}
+	if !strings.HasPrefix(rows[1], "   2 - func f() { foo(a, b) }") || !strings.Contains(rows[1], " |    2 + func f() { foo(a, c) }") {
+		t.Fatalf("unexpected changed row %q", rows[1])
+	}
+}
+
+func TestDetectDiff(t *testing.T) {
+	if d := Detect("fix.patch", nil); d.Kind != KindDiff {
+		t.Fatalf("fix.patch detected as %v", d.Kind)
+	}
+	if d := Detect("", []byte(samplePatch)); d.Kind != KindDiff {
+		t.Fatalf("content detected as %v", d.Kind)
+	}
+}
@@ -0,0 +1,330 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+const (
+	jsonKey    = "\x1b[38;5;117m"
+	jsonString = "\x1b[38;5;216m"
+	jsonNumber = "\x1b[38;5;141m"
+	jsonBool   = "\x1b[38;5;81m"
+	jsonNull   = "\x1b[38;5;204m"
+	jsonPunct  = "\x1b[38;5;250m"
+)
+
+const jsonIndent = "  "
+
+// renderJSON reindents a JSON document, keeping keys in their original order
+// and strings and numbers exactly as written. Invalid input is returned
+// highlighted but untouched, together with a warning that points at the
+// error.
+func renderJSON(name string, in []byte, opts Options) (string, []string) {
+	p := &jsonPrinter{src: in, color: opts.Color}
+	if err := p.document(); err != nil {
+		return highlight("json", in, opts), []string{err.describe(name, in)}
+	}
+	return p.b.String() + "\n", nil
+}
+
+type jsonPrinter struct {
+	src   []byte
+	pos   int
+	depth int
+	color bool
+	b     strings.Builder
+}
+
+type jsonError struct {
+	pos int
+	msg string
+}
+
+func (p *jsonPrinter) errorf(format string, args ...any) *jsonError {
+	return &jsonError{pos: p.pos, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *jsonPrinter) paint(style, s string) {
+	if p.color {
+		p.b.WriteString(style + s + ansiReset)
+		return
+	}
+	p.b.WriteString(s)
+}
+
+func (p *jsonPrinter) newline() {
+	p.b.WriteByte('\n')
+	p.b.WriteString(strings.Repeat(jsonIndent, p.depth))
+}
+
+func (p *jsonPrinter) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end of the input.
+func (p *jsonPrinter) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *jsonPrinter) unexpected() *jsonError {
+	if p.pos >= len(p.src) {
+		return p.errorf("unexpected end of input")
+	}
+	r, _ := utf8.DecodeRune(p.src[p.pos:])
+	return p.errorf("unexpected character %q", r)
+}
+
+func (p *jsonPrinter) document() *jsonError {
+	if len(p.src) >= 3 && string(p.src[:3]) == "\xef\xbb\xbf" {
+		p.pos = 3
+	}
+	if err := p.value(); err != nil {
+		return err
+	}
+	if p.peek() != 0 {
+		return p.errorf("unexpected data after the top-level value")
+	}
+	return nil
+}
+
+func (p *jsonPrinter) value() *jsonError {
+	switch c := p.peek(); {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		s, err := p.str()
+		if err != nil {
+			return err
+		}
+		p.paint(jsonString, s)
+	case c == '-' || isDigit(rune(c)):
+		n, err := p.number()
+		if err != nil {
+			return err
+		}
+		p.paint(jsonNumber, n)
+	case c == 't' || c == 'f' || c == 'n':
+		for _, lit := range []string{"true", "false", "null"} {
+			if strings.HasPrefix(string(p.src[p.pos:min(len(p.src), p.pos+len(lit))]), lit) {
+				p.pos += len(lit)
+				if lit == "null" {
+					p.paint(jsonNull, lit)
+				} else {
+					p.paint(jsonBool, lit)
+				}
+				return nil
+			}
+		}
+		return p.unexpected()
+	default:
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *jsonPrinter) object() *jsonError {
+	p.pos++
+	p.paint(jsonPunct, "{")
+	if p.peek() == '}' {
+		p.pos++
+		p.paint(jsonPunct, "}")
+		return nil
+	}
+	p.depth++
+	for {
+		if p.peek() != '"' {
+			if p.pos < len(p.src) {
+				return p.errorf("expected a string key")
+			}
+			return p.unexpected()
+		}
+		key, err := p.str()
+		if err != nil {
+			return err
+		}
+		p.newline()
+		p.paint(jsonKey, key)
+		if p.peek() != ':' {
+			return p.errorf("expected ':' after object key")
+		}
+		p.pos++
+		p.paint(jsonPunct, ":")
+		p.b.WriteByte(' ')
+		if err := p.value(); err != nil {
+			return err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+			p.paint(jsonPunct, ",")
+		case '}':
+			p.pos++
+			p.depth--
+			p.newline()
+			p.paint(jsonPunct, "}")
+			return nil
+		default:
+			return p.errorf("expected ',' or '}' after object value")
+		}
+	}
+}
+
+func (p *jsonPrinter) array() *jsonError {
+	p.pos++
+	p.paint(jsonPunct, "[")
+	if p.peek() == ']' {
+		p.pos++
+		p.paint(jsonPunct, "]")
+		return nil
+	}
+	p.depth++
+	for {
+		p.newline()
+		if err := p.value(); err != nil {
+			return err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+			p.paint(jsonPunct, ",")
+		case ']':
+			p.pos++
+			p.depth--
+			p.newline()
+			p.paint(jsonPunct, "]")
+			return nil
+		default:
+			return p.errorf("expected ',' or ']' after array element")
+		}
+	}
+}
+
+// str scans a string literal and returns it verbatim, quotes and escapes
+// included.
+func (p *jsonPrinter) str() (string, *jsonError) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return string(p.src[start:p.pos]), nil
+		case c == '\\':
+			p.pos++
+			if p.pos >= len(p.src) {
+				return "", p.unexpected()
+			}
+			switch p.src[p.pos] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				p.pos++
+			case 'u':
+				p.pos++
+				for i := 0; i < 4; i++ {
+					if p.pos >= len(p.src) || !isHexDigit(p.src[p.pos]) {
+						return "", p.errorf("invalid \\u escape in string")
+					}
+					p.pos++
+				}
+			default:
+				return "", p.errorf("invalid escape %q in string", "\\"+string(p.src[p.pos]))
+			}
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		default:
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *jsonPrinter) number() (string, *jsonError) {
+	start := p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && isDigit(rune(p.src[p.pos])) {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	switch {
+	case p.pos < len(p.src) && p.src[p.pos] == '0':
+		p.pos++
+	case digits() == 0:
+		return "", p.errorf("invalid number")
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		if digits() == 0 {
+			return "", p.errorf("expected digits after decimal point")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return "", p.errorf("expected digits in exponent")
+		}
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(rune(c)) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// describe formats the error as "name:line:col: msg" followed by the
+// offending line and a caret under the column.
+func (e *jsonError) describe(name string, src []byte) string {
+	line, col, text := sourcePosition(src, e.pos)
+	const window = 60
+	caret := col - 1
+	if caret > window {
+		cut := caret - window/2
+		text = "…" + string([]rune(text)[cut:])
+		caret = caret - cut + 1
+	}
+	if runes := []rune(text); len(runes) > caret+window {
+		text = string(runes[:caret+window]) + "…"
+	}
+	pad := style.Width(string([]rune(text)[:min(caret, utf8.RuneCountInString(text))]))
+	return fmt.Sprintf("%s:%d:%d: %s\n  %s\n  %s^", name, line, col, e.msg, text, strings.Repeat(" ", pad))
+}
+
+// sourcePosition converts a byte offset into a 1-based line and rune column,
+// and returns the text of that line.
+func sourcePosition(src []byte, pos int) (line, col int, text string) {
+	pos = min(pos, len(src))
+	start := strings.LastIndexByte(string(src[:pos]), '\n') + 1
+	end := len(src)
+	if i := strings.IndexByte(string(src[pos:]), '\n'); i >= 0 {
+		end = pos + i
+	}
+	line = strings.Count(string(src[:start]), "\n") + 1
+	col = utf8.RuneCount(src[start:pos]) + 1
+	text = strings.TrimRight(string(src[start:end]), "\r")
+	return line, col, strings.ReplaceAll(text, "\t", " ")
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderJSONReindentsPreservingOrderAndLiterals(t *testing.T) {
+	in := `{"z":1.10,"a":[true,null,"\u00e9"],"big":12345678901234567890,"e":{},"l":[]}`
+	out, warnings := renderJSON("x.json", []byte(in), Options{})
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %q", warnings)
+	}
+	want := "{\n" +
+		"  \"z\": 1.10,\n" +
+		"  \"a\": [\n" +
+		"    true,\n" +
+		"    null,\n" +
+		"    \"\\u00e9\"\n" +
+		"  ],\n" +
+		"  \"big\": 12345678901234567890,\n" +
+		"  \"e\": {},\n" +
+		"  \"l\": []\n" +
+		"}\n"
+	if out != want {
+		t.Fatalf("renderJSON mismatch:\n got %q\nwant %q", out, want)
+	}
+}
+
+func TestRenderJSONColorsTokens(t *testing.T) {
+	out, _ := renderJSON("x.json", []byte(`{"k": "v", "n": 2, "b": false, "z": null}`), Options{Color: true})
+	for _, want := range []string{
+		jsonKey + `"k"` + ansiReset,
+		jsonString + `"v"` + ansiReset,
+		jsonNumber + "2" + ansiReset,
+		jsonBool + "false" + ansiReset,
+		jsonNull + "null" + ansiReset,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestRenderJSONInvalidFallsBackWithCaret(t *testing.T) {
+	in := "{\n  \"a\": 1,\n  \"b\": 2 \"c\": 3\n}\n"
+	out, warnings := renderJSON("bad.json", []byte(in), Options{})
+	if out != in {
+		t.Fatalf("expected raw output, got %q", out)
+	}
+	want := "bad.json:3:10: expected ',' or '}' after object value\n" +
+		"    \"b\": 2 \"c\": 3\n" +
+		"           ^"
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Fatalf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestRenderJSONRejectsInvalidLiterals(t *testing.T) {
+	for _, in := range []string{`[01]`, `[1.]`, `{"a" 1}`, `"\x"`, `[1,]`, `tru`, `{} {}`, `"open`} {
+		if _, warnings := renderJSON("x.json", []byte(in), Options{}); len(warnings) == 0 {
+			t.Errorf("renderJSON(%q) accepted invalid JSON", in)
+		}
+	}
+}
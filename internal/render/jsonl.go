@@ -0,0 +1,213 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	logTime     = "\x1b[38;5;244m"
+	logKey      = "\x1b[38;5;244m"
+	logMessage  = "\x1b[1m"
+	logError    = "\x1b[38;5;203m"
+	logNonJSON  = "\x1b[38;5;250m"
+	logTimeSpec = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// logLevels maps normalized level names to their label and color.
+var logLevels = map[string]struct{ label, color string }{
+	"trace":   {"TRACE", "\x1b[38;5;244m"},
+	"debug":   {"DEBUG", "\x1b[38;5;117m"},
+	"info":    {"INFO", "\x1b[38;5;114m"},
+	"notice":  {"INFO", "\x1b[38;5;114m"},
+	"warn":    {"WARN", "\x1b[38;5;214m"},
+	"warning": {"WARN", "\x1b[38;5;214m"},
+	"error":   {"ERROR", "\x1b[38;5;203m"},
+	"err":     {"ERROR", "\x1b[38;5;203m"},
+	"fatal":   {"FATAL", "\x1b[1;38;5;196m"},
+	"panic":   {"FATAL", "\x1b[1;38;5;196m"},
+	"crit":    {"FATAL", "\x1b[1;38;5;196m"},
+}
+
+// numericLevels follows the bunyan/pino convention.
+var numericLevels = map[string]string{
+	"10": "trace", "20": "debug", "30": "info", "40": "warn", "50": "error", "60": "fatal",
+}
+
+// Keys promoted to the fixed prefix, in lookup order.
+var (
+	logTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+	logLevelKeys   = []string{"level", "lvl", "severity", "levelname", "log.level"}
+	logMessageKeys = []string{"msg", "message", "@message"}
+	logErrorKeys   = []string{"error", "err", "error.message", "exception"}
+)
+
+type logField struct {
+	key   string
+	value json.RawMessage
+}
+
+// renderJSONLines renders one JSON object per line as a log record: time,
+// level, message and error first, then the remaining fields as key=value.
+// Lines that are not JSON objects are passed through.
+func renderJSONLines(in []byte, opts Options) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(string(in), "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		fields, ok := parseLogRecord(line)
+		switch {
+		case ok:
+			b.WriteString(formatLogRecord(fields, opts.Color))
+		case opts.Color && strings.TrimSpace(line) != "":
+			b.WriteString(logNonJSON + line + ansiReset)
+		default:
+			b.WriteString(line)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func parseLogRecord(line string) ([]logField, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+	var fields []logField
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, false
+		}
+		fields = append(fields, logField{key: key, value: raw})
+	}
+	if _, err := dec.Token(); err != nil || dec.InputOffset() != int64(len(trimmed)) {
+		return nil, false
+	}
+	return fields, true
+}
+
+func formatLogRecord(fields []logField, color bool) string {
+	paint := func(style, s string) string {
+		if !color || s == "" {
+			return s
+		}
+		return style + s + ansiReset
+	}
+
+	used := make([]bool, len(fields))
+	take := func(keys []string) (json.RawMessage, bool) {
+		for _, k := range keys {
+			for i, f := range fields {
+				if !used[i] && strings.EqualFold(f.key, k) {
+					used[i] = true
+					return f.value, true
+				}
+			}
+		}
+		return nil, false
+	}
+
+	var parts []string
+	if v, ok := take(logTimeKeys); ok {
+		parts = append(parts, paint(logTime, logTimeText(v)))
+	}
+	if v, ok := take(logLevelKeys); ok {
+		label, style := logLevel(v)
+		parts = append(parts, paint(style, label+strings.Repeat(" ", max(5-len(label), 0))))
+	}
+	if v, ok := take(logMessageKeys); ok {
+		parts = append(parts, paint(logMessage, logText(v)))
+	}
+	if v, ok := take(logErrorKeys); ok {
+		parts = append(parts, paint(logKey, "error=")+paint(logError, logValue(v)))
+	}
+	for i, f := range fields {
+		if used[i] {
+			continue
+		}
+		parts = append(parts, paint(logKey, f.key+"=")+logColoredValue(f.value, color))
+	}
+	return strings.Join(parts, " ")
+}
+
+// logText returns strings unquoted and anything else as compact JSON.
+func logText(v json.RawMessage) string {
+	var s string
+	if len(v) > 0 && v[0] == '"' && json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	var b bytes.Buffer
+	if json.Compact(&b, v) == nil {
+		return b.String()
+	}
+	return string(v)
+}
+
+// logValue is logText, quoting strings that would be ambiguous in a
+// key=value list.
+func logValue(v json.RawMessage) string {
+	s := logText(v)
+	if len(v) > 0 && v[0] == '"' && (s == "" || strings.ContainsAny(s, " \t\n\"=")) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func logColoredValue(v json.RawMessage, color bool) string {
+	s := logValue(v)
+	if !color || len(v) == 0 {
+		return s
+	}
+	style := jsonPunct
+	switch c := v[0]; {
+	case c == '"':
+		style = jsonString
+	case c == 't' || c == 'f':
+		style = jsonBool
+	case c == 'n':
+		style = jsonNull
+	case c == '-' || isDigit(rune(c)):
+		style = jsonNumber
+	}
+	return style + s + ansiReset
+}
+
+// logTimeText formats numeric Unix timestamps (seconds or milliseconds) and
+// leaves string timestamps as written.
+func logTimeText(v json.RawMessage) string {
+	f, err := strconv.ParseFloat(string(v), 64)
+	if err != nil {
+		return logText(v)
+	}
+	var t time.Time
+	if f > 1e12 {
+		t = time.UnixMilli(int64(f))
+	} else {
+		t = time.UnixMilli(int64(f * 1000))
+	}
+	return t.UTC().Format(logTimeSpec)
+}
+
+func logLevel(v json.RawMessage) (label, style string) {
+	name := strings.ToLower(logText(v))
+	if n, ok := numericLevels[name]; ok {
+		name = n
+	}
+	if lvl, ok := logLevels[name]; ok {
+		return lvl.label, lvl.color
+	}
+	return strings.ToUpper(name), logKey
+}
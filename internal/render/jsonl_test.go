@@ -0,0 +1,50 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderJSONLinesPromotesKnownFields(t *testing.T) {
+	in := `{"level":"warn","port":8080,"msg":"slow start","time":"2024-05-01T10:00:00Z","tags":["a","b"],"note":"two words","x":null}` + "\n"
+	got := renderJSONLines([]byte(in), Options{})
+	want := `2024-05-01T10:00:00Z WARN  slow start port=8080 tags=["a","b"] note="two words" x=null` + "\n"
+	if got != want {
+		t.Fatalf("renderJSONLines mismatch:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderJSONLinesNumericLevelsAndEpochTimes(t *testing.T) {
+	in := `{"ts":1714557600.5,"level":50,"msg":"failed","err":"dial tcp: timeout"}` + "\n"
+	got := renderJSONLines([]byte(in), Options{})
+	want := `2024-05-01T10:00:00.500Z ERROR failed error="dial tcp: timeout"` + "\n"
+	if got != want {
+		t.Fatalf("renderJSONLines mismatch:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderJSONLinesToleratesOtherLines(t *testing.T) {
+	in := "{\"msg\":\"ok\"}\ngoroutine 1 [running]:\n{broken\n\n"
+	got := renderJSONLines([]byte(in), Options{})
+	want := "ok\ngoroutine 1 [running]:\n{broken\n"
+	if got != want {
+		t.Fatalf("renderJSONLines mismatch:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderJSONLinesColorsLevels(t *testing.T) {
+	got := renderJSONLines([]byte(`{"level":"error","msg":"boom"}`+"\n"), Options{Color: true})
+	if !strings.HasPrefix(got, logLevels["error"].color+"ERROR"+ansiReset) {
+		t.Fatalf("expected a red level label, got %q", got)
+	}
+}
+
+func TestDetectJSONLinesFromContent(t *testing.T) {
+	data := "{\"a\":1}\nnot json\n{\"a\":2}\n{\"a\":3}\n"
+	if got := Detect("stdin", []byte(data)); got != (Detection{KindLog, "jsonl"}) {
+		t.Fatalf("Detect = %+v, want log/jsonl", got)
+	}
+	if got := Detect("app.ndjson", nil); got.Kind != KindLog {
+		t.Fatalf("Detect(app.ndjson) kind = %q, want log", got.Kind)
+	}
+}
@@ -0,0 +1,138 @@
+package render
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Language struct {
+	ID            string
+	Name          string
+	Aliases       []string
+	Extensions    []string
+	Keywords      []string
+	Types         []string
+	Builtins      []string
+	LineComments  []string
+	BlockComments []CommentRule
+	Strings       []StringRule
+	// IdentChars lists extra runes allowed in identifiers, e.g. "$" in JS.
+	IdentChars string
+
+	classes map[string]TokenClass
+}
+
+type CommentRule struct {
+	Open  string
+	Close string
+}
+
+type StringRule struct {
+	Open      string
+	Close     string
+	Escape    bool
+	Multiline bool
+}
+
+var (
+	dq       = StringRule{Open: `"`, Close: `"`, Escape: true}
+	sq       = StringRule{Open: `'`, Close: `'`, Escape: true}
+	backtick = StringRule{Open: "`", Close: "`", Multiline: true}
+	cBlock   = CommentRule{Open: "/*", Close: "*/"}
+)
+
+// genericLanguage is used when no language definition matches the input. It
+// only knows about quoted strings and numbers.
+var genericLanguage = &Language{
+	ID:      "generic",
+	Name:    "Generic",
+	Strings: []StringRule{dq, sq},
+}
+
+var (
+	languagesByID  = map[string]*Language{}
+	languagesByExt = map[string]*Language{}
+)
+
+func init() {
+	for _, lang := range languages {
+		registerLanguage(lang)
+	}
+}
+
+func registerLanguage(lang *Language) {
+	lang.classes = make(map[string]TokenClass, len(lang.Keywords)+len(lang.Types)+len(lang.Builtins))
+	for _, w := range lang.Builtins {
+		lang.classes[w] = TokenBuiltin
+	}
+	for _, w := range lang.Types {
+		lang.classes[w] = TokenType
+	}
+	for _, w := range lang.Keywords {
+		lang.classes[w] = TokenKeyword
+	}
+	languagesByID[lang.ID] = lang
+	for _, alias := range lang.Aliases {
+		languagesByID[alias] = lang
+	}
+	for _, ext := range lang.Extensions {
+		languagesByExt[ext] = lang
+	}
+}
+
+func (lang *Language) classify(word string) TokenClass {
+	if class, ok := lang.classes[word]; ok {
+		return class
+	}
+	return TokenIdentifier
+}
+
+// LanguageByID resolves a language ID or alias, case-insensitively.
+func LanguageByID(id string) (*Language, bool) {
+	lang, ok := languagesByID[strings.ToLower(strings.TrimSpace(id))]
+	return lang, ok
+}
+
+// LanguageForName picks a language from the file extension of name.
+func LanguageForName(name string) (*Language, bool) {
+	lang, ok := languagesByExt[strings.ToLower(filepath.Ext(name))]
+	return lang, ok
+}
+
+// LanguageInfo describes a renderer choice for --list-languages.
+type LanguageInfo struct {
+	ID      string
+	Name    string
+	Aliases []string
+	// Files lists extension globs and well-known file names.
+	Files []string
+}
+
+// SupportedLanguages lists Markdown, every highlighted language and plain
+// text, in that order.
+func SupportedLanguages() []LanguageInfo {
+	var mdFiles []string
+	for ext := range markdownExt {
+		mdFiles = append(mdFiles, "*"+ext)
+	}
+	sort.Strings(mdFiles)
+	infos := []LanguageInfo{{ID: "markdown", Name: "Markdown", Aliases: []string{"md", "gfm"}, Files: mdFiles}}
+
+	for _, lang := range languages {
+		var files []string
+		for _, ext := range lang.Extensions {
+			files = append(files, "*"+ext)
+		}
+		var names []string
+		for name, id := range filenameLanguages {
+			if id == lang.ID {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		infos = append(infos, LanguageInfo{ID: lang.ID, Name: lang.Name, Aliases: lang.Aliases, Files: append(files, names...)})
+	}
+
+	return append(infos, LanguageInfo{ID: "plain", Name: "Plain text", Aliases: []string{"text", "txt"}})
+}
@@ -0,0 +1,285 @@
+package render
+
+var languages = []*Language{
+	{
+		ID:         "go",
+		Name:       "Go",
+		Aliases:    []string{"golang"},
+		Extensions: []string{".go"},
+		Keywords: []string{
+			"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+			"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+			"return", "select", "struct", "switch", "type", "var",
+		},
+		Types: []string{
+			"any", "bool", "byte", "comparable", "complex64", "complex128", "error", "float32", "float64",
+			"int", "int8", "int16", "int32", "int64", "rune", "string",
+			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		},
+		Builtins: []string{
+			"append", "cap", "clear", "close", "complex", "copy", "delete", "false", "imag", "iota", "len",
+			"make", "max", "min", "new", "nil", "panic", "print", "println", "real", "recover", "true",
+		},
+		LineComments:  []string{"//"},
+		BlockComments: []CommentRule{cBlock},
+		Strings:       []StringRule{dq, sq, backtick},
+	},
+	{
+		ID:         "javascript",
+		Name:       "JavaScript",
+		Aliases:    []string{"js", "node", "nodejs"},
+		Extensions: []string{".js", ".mjs", ".cjs", ".jsx"},
+		Keywords: []string{
+			"async", "await", "break", "case", "catch", "class", "const", "continue", "debugger", "default",
+			"delete", "do", "else", "export", "extends", "finally", "for", "from", "function", "if", "import",
+			"in", "instanceof", "let", "new", "of", "return", "static", "super", "switch", "this", "throw",
+			"try", "typeof", "var", "void", "while", "with", "yield",
+		},
+		Builtins: []string{
+			"Array", "Boolean", "Date", "Error", "JSON", "Map", "Math", "Number", "Object", "Promise",
+			"Set", "String", "Symbol", "console", "document", "false", "null", "true", "undefined", "window",
+		},
+		LineComments:  []string{"//"},
+		BlockComments: []CommentRule{cBlock},
+		Strings:       []StringRule{dq, sq, {Open: "`", Close: "`", Escape: true, Multiline: true}},
+		IdentChars:    "$",
+	},
+	{
+		ID:         "typescript",
+		Name:       "TypeScript",
+		Aliases:    []string{"ts"},
+		Extensions: []string{".ts", ".tsx", ".mts", ".cts"},
+		Keywords: []string{
+			"abstract", "as", "async", "await", "break", "case", "catch", "class", "const", "continue",
+			"declare", "default", "delete", "do", "else", "enum", "export", "extends", "finally", "for",
+			"from", "function", "if", "implements", "import", "in", "instanceof", "interface", "keyof", "let",
+			"namespace", "new", "of", "private", "protected", "public", "readonly", "return", "static",
+			"super", "switch", "this", "throw", "try", "type", "typeof", "var", "void", "while", "yield",
+		},
+		Types: []string{
+			"any", "bigint", "boolean", "never", "number", "object", "string", "symbol", "unknown",
+		},
+		Builtins: []string{
+			"Array", "Date", "Error", "JSON", "Map", "Math", "Object", "Promise", "Record", "Set",
+			"console", "false", "null", "true", "undefined",
+		},
+		LineComments:  []string{"//"},
+		BlockComments: []CommentRule{cBlock},
+		Strings:       []StringRule{dq, sq, {Open: "`", Close: "`", Escape: true, Multiline: true}},
+		IdentChars:    "$",
+	},
+	{
+		ID:         "python",
+		Name:       "Python",
+		Aliases:    []string{"py", "python3"},
+		Extensions: []string{".py", ".pyw", ".pyi"},
+		Keywords: []string{
+			"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif",
+			"else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda",
+			"match", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+		},
+		Types: []string{"bool", "bytes", "dict", "float", "frozenset", "int", "list", "set", "str", "tuple"},
+		Builtins: []string{
+			"False", "None", "True", "abs", "all", "any", "enumerate", "filter", "isinstance", "len",
+			"map", "open", "print", "range", "self", "sorted", "super", "sum", "type", "zip",
+		},
+		LineComments: []string{"#"},
+		Strings: []StringRule{
+			{Open: `"""`, Close: `"""`, Escape: true, Multiline: true},
+			{Open: `'''`, Close: `'''`, Escape: true, Multiline: true},
+			dq, sq,
+		},
+	},
+	{
+		ID:         "ruby",
+		Name:       "Ruby",
+		Aliases:    []string{"rb"},
+		Extensions: []string{".rb", ".rake", ".gemspec"},
+		Keywords: []string{
+			"alias", "and", "begin", "break", "case", "class", "def", "do", "else", "elsif",
+			"end", "ensure", "for", "if", "in", "module", "next", "not", "or", "redo", "rescue", "retry",
+			"return", "self", "super", "then", "undef", "unless", "until", "when", "while", "yield",
+		},
+		Builtins: []string{
+			"attr_accessor", "attr_reader", "attr_writer", "false", "include", "nil", "puts", "raise",
+			"require", "require_relative", "true",
+		},
+		LineComments:  []string{"#"},
+		BlockComments: []CommentRule{{Open: "=begin", Close: "=end"}},
+		Strings:       []StringRule{dq, sq},
+		IdentChars:    "@",
+	},
+	{
+		ID:         "java",
+		Name:       "Java",
+		Extensions: []string{".java"},
+		Keywords: []string{
+			"abstract", "assert", "break", "case", "catch", "class", "continue", "default", "do", "else",
+			"enum", "extends", "final", "finally", "for", "if", "implements", "import", "instanceof",
+			"interface", "native", "new", "package", "private", "protected", "public", "record", "return",
+			"static", "super", "switch", "synchronized", "this", "throw", "throws", "try", "var", "void",
+			"volatile", "while",
+		},
+		Types: []string{
+			"boolean", "byte", "char", "double", "float", "int", "long", "short",
+			"Integer", "List", "Map", "Object", "String",
+		},
+		Builtins:      []string{"false", "null", "true", "System"},
+		LineComments:  []string{"//"},
+		BlockComments: []CommentRule{cBlock},
+		Strings:       []StringRule{{Open: `"""`, Close: `"""`, Escape: true, Multiline: true}, dq, sq},
+	},
+	{
+		ID:         "c",
+		Name:       "C",
+		Extensions: []string{".c", ".h"},
+		Keywords: []string{
+			"break", "case", "const", "continue", "default", "do", "else", "enum", "extern", "for", "goto",
+			"if", "inline", "register", "return", "sizeof", "static", "struct", "switch", "typedef", "union",
+			"volatile", "while", "#define", "#include", "#ifdef", "#ifndef", "#endif", "#if", "#else",
+		},
+		Types: []string{
+			"char", "double", "float", "int", "long", "short", "signed", "unsigned", "void",
+			"size_t", "int8_t", "int16_t", "int32_t", "int64_t", "uint8_t", "uint16_t", "uint32_t", "uint64_t",
+		},
+		Builtins:      []string{"NULL", "false", "true", "printf", "malloc", "free"},
+		LineComments:  []string{"//"},
+		BlockComments: []CommentRule{cBlock},
+		Strings:       []StringRule{dq, sq},
+		IdentChars:    "#",
+	},
+	{
+		ID:         "cpp",
+		Name:       "C++",
+		Aliases:    []string{"c++", "cxx"},
+		Extensions: []string{".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx"},
+		Keywords: []string{
+			"auto", "break", "case", "catch", "class", "const", "constexpr", "continue", "default", "delete",
+			"do", "else", "enum", "explicit", "extern", "for", "friend", "if", "inline", "namespace", "new",
+			"noexcept", "operator", "override", "private", "protected", "public", "return", "sizeof",
+			"static", "struct", "switch", "template", "this", "throw", "try", "typedef", "typename",
+			"using", "virtual", "while", "#define", "#include", "#ifdef", "#ifndef", "#endif", "#pragma",
+		},
+		Types: []string{
+			"bool", "char", "double", "float", "int", "long", "short", "signed", "unsigned", "void",
+			"size_t", "string", "vector",
+		},
+		Builtins:      []string{"false", "nullptr", "std", "true"},
+		LineComments:  []string{"//"},
+		BlockComments: []CommentRule{cBlock},
+		Strings:       []StringRule{dq, sq},
+		IdentChars:    "#",
+	},
+	{
+		ID:         "rust",
+		Name:       "Rust",
+		Aliases:    []string{"rs"},
+		Extensions: []string{".rs"},
+		Keywords: []string{
+			"as", "async", "await", "break", "const", "continue", "crate", "dyn", "else", "enum", "extern",
+			"fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref",
+			"return", "self", "static", "struct", "super", "trait", "type", "unsafe", "use", "where", "while",
+		},
+		Types: []string{
+			"bool", "char", "f32", "f64", "i8", "i16", "i32", "i64", "i128", "isize", "str",
+			"u8", "u16", "u32", "u64", "u128", "usize", "Box", "Option", "Result", "Self", "String", "Vec",
+		},
+		Builtins:      []string{"Err", "None", "Ok", "Some", "false", "true", "println", "format", "vec"},
+		LineComments:  []string{"//"},
+		BlockComments: []CommentRule{cBlock},
+		Strings:       []StringRule{{Open: `"`, Close: `"`, Escape: true, Multiline: true}},
+	},
+	{
+		ID:         "shell",
+		Name:       "Shell",
+		Aliases:    []string{"sh", "bash", "zsh", "ksh", "dash", "ash", "shell-script"},
+		Extensions: []string{".sh", ".bash", ".zsh"},
+		Keywords: []string{
+			"case", "do", "done", "elif", "else", "esac", "export", "fi", "for", "function", "if", "in",
+			"local", "readonly", "return", "select", "then", "until", "while",
+		},
+		Builtins: []string{
+			"cd", "echo", "eval", "exec", "exit", "printf", "read", "set", "shift", "source", "test",
+			"trap", "unset",
+		},
+		LineComments: []string{"#"},
+		Strings:      []StringRule{{Open: `"`, Close: `"`, Escape: true, Multiline: true}, {Open: `'`, Close: `'`, Multiline: true}},
+		IdentChars:   "$",
+	},
+	{
+		ID:         "json",
+		Name:       "JSON",
+		Aliases:    []string{"jsonc", "json5"},
+		Extensions: []string{".json"},
+		Builtins:   []string{"false", "null", "true"},
+		Strings:    []StringRule{dq},
+	},
+	{
+		ID:         "make",
+		Name:       "Makefile",
+		Aliases:    []string{"makefile", "mk", "gnumake"},
+		Extensions: []string{".mk", ".mak"},
+		Keywords: []string{
+			"define", "else", "endef", "endif", "export", "ifdef", "ifeq", "ifndef", "ifneq", "include",
+			"override", "unexport", "vpath", "-include", "sinclude",
+		},
+		Builtins: []string{
+			"addprefix", "addsuffix", "basename", "call", "dir", "error", "eval", "filter", "filter-out",
+			"findstring", "foreach", "info", "notdir", "patsubst", "shell", "sort", "strip", "subst",
+			"warning", "wildcard", "word", "words",
+		},
+		LineComments: []string{"#"},
+		Strings:      []StringRule{dq, sq},
+		IdentChars:   "-.",
+	},
+	{
+		ID:         "dockerfile",
+		Name:       "Dockerfile",
+		Aliases:    []string{"docker", "containerfile"},
+		Extensions: []string{".dockerfile"},
+		Keywords: []string{
+			"ADD", "ARG", "AS", "CMD", "COPY", "ENTRYPOINT", "ENV", "EXPOSE", "FROM", "HEALTHCHECK",
+			"LABEL", "MAINTAINER", "ONBUILD", "RUN", "SHELL", "STOPSIGNAL", "USER", "VOLUME", "WORKDIR",
+		},
+		LineComments: []string{"#"},
+		Strings:      []StringRule{dq, sq},
+	},
+	{
+		ID:           "gomod",
+		Name:         "Go module",
+		Aliases:      []string{"go.mod", "gowork", "go.work"},
+		Keywords:     []string{"exclude", "go", "godebug", "module", "replace", "require", "retract", "tool", "toolchain"},
+		LineComments: []string{"//"},
+		Strings:      []StringRule{dq, backtick},
+	},
+	{
+		ID:         "jsonl",
+		Name:       "JSON Lines",
+		Aliases:    []string{"ndjson", "jsonlines"},
+		Extensions: []string{".jsonl", ".ndjson"},
+		Builtins:   []string{"false", "null", "true"},
+		Strings:    []StringRule{dq},
+	},
+	{
+		ID:           "yaml",
+		Name:         "YAML",
+		Aliases:      []string{"yml"},
+		Extensions:   []string{".yaml", ".yml"},
+		Builtins:     []string{"false", "null", "true"},
+		LineComments: []string{"#"},
+		Strings:      []StringRule{dq, {Open: `'`, Close: `'`}},
+	},
+	{
+		ID:         "csv",
+		Name:       "CSV",
+		Aliases:    []string{"tsv"},
+		Extensions: []string{".csv", ".tsv"},
+		Strings:    []StringRule{{Open: `"`, Close: `"`, Multiline: true}},
+	},
+	{
+		ID:         "diff",
+		Name:       "Diff",
+		Aliases:    []string{"patch", "udiff"},
+		Extensions: []string{".diff", ".patch"},
+	},
+}
@@ -0,0 +1,172 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenClass int
+
+const (
+	TokenText TokenClass = iota
+	TokenKeyword
+	TokenIdentifier
+	TokenString
+	TokenNumber
+	TokenComment
+	TokenOperator
+	TokenType
+	TokenBuiltin
+)
+
+type Token struct {
+	Class TokenClass
+	Text  string
+}
+
+const operatorChars = "+-*/%=<>!&|^~?:"
+
+// Lex splits src into tokens using the rules of lang. Every byte of src ends
+// up in exactly one token, so concatenating the token texts yields src.
+func Lex(lang *Language, src string) []Token {
+	lx := lexer{lang: lang, src: src}
+	return lx.run()
+}
+
+type lexer struct {
+	lang   *Language
+	src    string
+	pos    int
+	tokens []Token
+}
+
+func (lx *lexer) run() []Token {
+	for lx.pos < len(lx.src) {
+		start := lx.pos
+		class := lx.next()
+		lx.emit(class, lx.src[start:lx.pos])
+	}
+	return lx.tokens
+}
+
+func (lx *lexer) emit(class TokenClass, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(lx.tokens); n > 0 && lx.tokens[n-1].Class == class && (class == TokenText || class == TokenOperator) {
+		lx.tokens[n-1].Text += text
+		return
+	}
+	lx.tokens = append(lx.tokens, Token{Class: class, Text: text})
+}
+
+// next consumes one token starting at lx.pos and reports its class.
+func (lx *lexer) next() TokenClass {
+	rest := lx.src[lx.pos:]
+
+	for _, bc := range lx.lang.BlockComments {
+		if strings.HasPrefix(rest, bc.Open) {
+			end := strings.Index(rest[len(bc.Open):], bc.Close)
+			if end < 0 {
+				lx.pos = len(lx.src)
+			} else {
+				lx.pos += len(bc.Open) + end + len(bc.Close)
+			}
+			return TokenComment
+		}
+	}
+	for _, lc := range lx.lang.LineComments {
+		if strings.HasPrefix(rest, lc) {
+			lx.pos += lineEnd(rest)
+			return TokenComment
+		}
+	}
+	for _, sr := range lx.lang.Strings {
+		if strings.HasPrefix(rest, sr.Open) {
+			lx.pos += sr.scan(rest)
+			return TokenString
+		}
+	}
+
+	r, size := utf8.DecodeRuneInString(rest)
+	switch {
+	case isDigit(r) || (r == '.' && len(rest) > 1 && isDigit(rune(rest[1]))):
+		lx.pos += scanNumber(rest)
+		return TokenNumber
+	case lx.isIdentStart(r):
+		n := lx.scanIdent(rest)
+		lx.pos += n
+		return lx.lang.classify(rest[:n])
+	case strings.ContainsRune(operatorChars, r):
+		lx.pos += size
+		return TokenOperator
+	default:
+		lx.pos += size
+		return TokenText
+	}
+}
+
+func (lx *lexer) isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || strings.ContainsRune(lx.lang.IdentChars, r)
+}
+
+func (lx *lexer) scanIdent(s string) int {
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !lx.isIdentStart(r) && !unicode.IsDigit(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+func (sr StringRule) scan(s string) int {
+	i := len(sr.Open)
+	for i < len(s) {
+		switch {
+		case sr.Escape && s[i] == '\\' && i+1 < len(s):
+			i += 2
+		case strings.HasPrefix(s[i:], sr.Close):
+			return i + len(sr.Close)
+		case s[i] == '\n' && !sr.Multiline:
+			return i
+		default:
+			i++
+		}
+	}
+	return len(s)
+}
+
+func scanNumber(s string) int {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case isDigit(rune(c)) || c == '_' || c == '.' || unicode.IsLetter(rune(c)):
+			i++
+		case (c == '+' || c == '-') && i > 0 && (s[i-1] == 'e' || s[i-1] == 'E') && !isHexPrefixed(s):
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isHexPrefixed(s string) bool {
+	return len(s) > 1 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func lineEnd(s string) int {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return i
+	}
+	return len(s)
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexCoversEveryByte(t *testing.T) {
+	src := "package main\n\n/* block\ncomment */\nfunc main() {\n\ts := `raw\nstring` // tail\n\tx := 0x1F + 3.5e-2\n}\n"
+	lang, _ := LanguageByID("go")
+	var b strings.Builder
+	for _, tok := range Lex(lang, src) {
+		b.WriteString(tok.Text)
+	}
+	if b.String() != src {
+		t.Fatalf("tokens do not reassemble the input:\n got %q\nwant %q", b.String(), src)
+	}
+}
+
+func TestLexClassifiesTokens(t *testing.T) {
+	lang, _ := LanguageByID("go")
+	src := `func f(s string) int { return len("if for") + 42 } // return nil`
+
+	got := map[string]TokenClass{}
+	for _, tok := range Lex(lang, src) {
+		got[tok.Text] = tok.Class
+	}
+
+	want := map[string]TokenClass{
+		"func":          TokenKeyword,
+		"f":             TokenIdentifier,
+		"string":        TokenType,
+		"return":        TokenKeyword,
+		"len":           TokenBuiltin,
+		`"if for"`:      TokenString,
+		"42":            TokenNumber,
+		"+":             TokenOperator,
+		"// return nil": TokenComment,
+	}
+	for text, class := range want {
+		if got[text] != class {
+			t.Errorf("token %q class = %d, want %d", text, got[text], class)
+		}
+	}
+}
+
+func TestLexPythonTripleQuotedString(t *testing.T) {
+	lang, _ := LanguageByID("python")
+	toks := Lex(lang, "x = \"\"\"def\n# not a comment\"\"\"\n")
+	for _, tok := range toks {
+		if tok.Class == TokenComment || tok.Class == TokenKeyword {
+			t.Fatalf("unexpected %d token %q inside string", tok.Class, tok.Text)
+		}
+	}
+}
+
+func TestThemePaintKeepsKeywordsInStringsUncolored(t *testing.T) {
+	out := highlight("go", []byte(`s := "return // not comment"`+"\n"), Options{Color: true})
+	want := defaultTheme[TokenString] + `"return // not comment"` + ansiReset
+	if !strings.Contains(out, want) {
+		t.Fatalf("expected string painted as a single token, got %q", out)
+	}
+}
+
+func TestThemePaintResetsStyleAtLineEnds(t *testing.T) {
+	lang, _ := LanguageByID("go")
+	out := defaultTheme.Paint(Lex(lang, "/* a\nb */"))
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasSuffix(line, ansiReset) {
+			t.Fatalf("line %q does not end with a reset", line)
+		}
+	}
+}
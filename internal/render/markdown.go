@@ -1,73 +1,530 @@
 package render
 
 import (
-	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/rodrwan/prettycat/internal/markdown"
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+const (
+	mdBullet    = "\x1b[38;5;212m"
+	mdQuote     = "\x1b[38;5;244m"
+	mdCode      = "\x1b[38;5;179m"
+	mdFrame     = "\x1b[38;5;244m"
+	mdRule      = "\x1b[38;5;240m"
+	mdLink      = "\x1b[4;38;5;75m"
+	mdURL       = "\x1b[38;5;244m"
+	mdHTML      = "\x1b[38;5;244m"
+	mdBold      = "\x1b[1m"
+	mdItalic    = "\x1b[3m"
+	mdStrike    = "\x1b[9m"
+	mdTaskDone  = "\x1b[38;5;114m"
+	mdTableGrid = "\x1b[38;5;240m"
 )
 
+var mdHeadings = []string{
+	"\x1b[1;38;5;159m",
+	"\x1b[1;38;5;117m",
+	"\x1b[1;38;5;111m",
+	"\x1b[1;38;5;110m",
+	"\x1b[1;38;5;109m",
+	"\x1b[1;38;5;103m",
+}
+
 func renderMarkdown(in []byte, opts Options) (string, error) {
-	s := string(in)
-	if !opts.Color {
-		return renderPlain([]byte(s)), nil
+	doc := markdown.Parse(in)
+	r := &mdRenderer{opts: opts}
+	if opts.wrapProse() {
+		r.width = opts.Width
 	}
+	lines := r.blocks(doc, false)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// minWrapWidth keeps deeply nested blocks readable on narrow terminals.
+const minWrapWidth = 20
+
+type mdRenderer struct {
+	opts Options
+	// width is the room left for the block being rendered; 0 disables
+	// reflowing.
+	width int
+}
 
-	lines := strings.Split(s, "\n")
-	var out strings.Builder
-	inCode := false
+// nested renders fn with indent fewer columns available.
+func (r *mdRenderer) nested(indent int, fn func() []string) []string {
+	if r.width == 0 {
+		return fn()
+	}
+	saved := r.width
+	r.width = max(r.width-indent, minWrapWidth)
+	defer func() { r.width = saved }()
+	return fn()
+}
+
+// reflow splits rendered inline text into lines that fit the current width.
+func (r *mdRenderer) reflow(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		out = append(out, wrapText(line, r.width)...)
+	}
+	return out
+}
+
+func (r *mdRenderer) paint(style, s string) string {
+	if !r.opts.Color || s == "" {
+		return s
+	}
+	return style + s + ansiReset
+}
 
+// blocks renders the children of parent, separated by blank lines unless
+// they belong to a tight list item.
+func (r *mdRenderer) blocks(parent *markdown.Node, tight bool) []string {
+	var out []string
+	for n := parent.FirstChild; n != nil; n = n.Next {
+		lines := r.block(n)
+		if len(lines) == 0 {
+			continue
+		}
+		if len(out) > 0 && !tight {
+			out = append(out, "")
+		}
+		out = append(out, lines...)
+	}
+	return out
+}
+
+func (r *mdRenderer) block(n *markdown.Node) []string {
+	switch n.Type {
+	case markdown.Paragraph:
+		return r.reflow(r.inlines(n, ""))
+	case markdown.Heading:
+		if !r.opts.Color {
+			return r.reflow(strings.Repeat("#", n.Level) + " " + r.inlines(n, ""))
+		}
+		return r.reflow(r.inlines(n, mdHeadings[n.Level-1]))
+	case markdown.ThematicBreak:
+		if !r.opts.Color {
+			return []string{strings.Repeat("-", r.ruleWidth())}
+		}
+		return []string{r.paint(mdRule, strings.Repeat("─", r.ruleWidth()))}
+	case markdown.CodeBlock:
+		return r.codeBlock(n)
+	case markdown.HTMLBlock:
+		lines := strings.Split(n.Literal, "\n")
+		for i, line := range lines {
+			lines[i] = r.paint(mdHTML, line)
+		}
+		return lines
+	case markdown.BlockQuote:
+		bar := "> "
+		if r.opts.Color {
+			bar = r.paint(mdQuote, "│ ")
+		}
+		lines := r.nested(2, func() []string { return r.blocks(n, false) })
+		for i, line := range lines {
+			lines[i] = bar + line
+		}
+		return lines
+	case markdown.List:
+		return r.list(n)
+	case markdown.Table:
+		return r.table(n)
+	}
+	return nil
+}
+
+func (r *mdRenderer) ruleWidth() int {
+	if r.opts.Width > 0 && r.opts.Width < 80 {
+		return r.opts.Width
+	}
+	return 40
+}
+
+func (r *mdRenderer) codeBlock(n *markdown.Node) []string {
+	source := strings.TrimSuffix(n.Literal, "\n")
+	if !r.opts.Color {
+		fence := "```"
+		out := append([]string{fence + n.Info}, r.wrapCode(strings.Split(source, "\n"))...)
+		return append(out, fence)
+	}
+
+	label := "code"
+	var body []string
+	lang, ok := fenceLanguage(n.Info)
+	if ok {
+		label = lang.Name
+		body = strings.Split(defaultTheme.Paint(Lex(lang, source)), "\n")
+	} else {
+		if fields := strings.Fields(n.Info); len(fields) > 0 {
+			label = fields[0]
+		}
+		for _, line := range strings.Split(source, "\n") {
+			body = append(body, r.paint(mdCode, line))
+		}
+	}
+	out := []string{r.paint(mdFrame, "┌ "+label)}
+	out = append(out, r.wrapCode(body)...)
+	return append(out, r.paint(mdFrame, "└"))
+}
+
+func (r *mdRenderer) wrapCode(lines []string) []string {
+	if r.width == 0 || !r.opts.wrapCode() {
+		return lines
+	}
+	var out []string
 	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "```") {
-			inCode = !inCode
-			if inCode {
-				out.WriteString("\x1b[38;5;244m┌ code\x1b[0m\n")
-			} else {
-				out.WriteString("\x1b[38;5;244m└\x1b[0m\n")
+		out = append(out, wrapText(line, r.width)...)
+	}
+	return out
+}
+
+// fenceLanguage looks up the language named by the first word of a fenced
+// block's info string, accepting the "{.lang}" attribute form too.
+func fenceLanguage(info string) (*Language, bool) {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	id := strings.TrimPrefix(strings.Trim(fields[0], "{}"), ".")
+	return LanguageByID(id)
+}
+
+func (r *mdRenderer) list(n *markdown.Node) []string {
+	var markers []string
+	width := 0
+	num := n.List.Start
+	for item := n.FirstChild; item != nil; item = item.Next {
+		m := "•"
+		if !r.opts.Color {
+			m = "-"
+		}
+		if n.List.Ordered {
+			m = strconv.Itoa(num) + string(n.List.Delimiter)
+			num++
+		}
+		markers = append(markers, m)
+		width = max(width, len([]rune(m)))
+	}
+
+	var out []string
+	i := 0
+	for item := n.FirstChild; item != nil; item = item.Next {
+		marker := markers[i]
+		i++
+		pad := strings.Repeat(" ", width-len([]rune(marker)))
+		head := r.paint(mdBullet, marker) + pad + " "
+		if n.List.Ordered {
+			head = pad + r.paint(mdBullet, marker) + " "
+		}
+		indent := strings.Repeat(" ", width+1)
+		if item.Task {
+			head += r.taskBox(item.Checked) + " "
+			indent += "    "
+		}
+
+		lines := r.nested(len(indent), func() []string { return r.blocks(item, n.List.Tight) })
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		if len(out) > 0 && !n.List.Tight {
+			out = append(out, "")
+		}
+		for j, line := range lines {
+			switch {
+			case j == 0:
+				out = append(out, strings.TrimRight(head+line, " "))
+			case line == "":
+				out = append(out, "")
+			default:
+				out = append(out, indent+line)
 			}
-			continue
 		}
-		if inCode {
-			out.WriteString("\x1b[38;5;179m" + line + "\x1b[0m\n")
-			continue
+	}
+	return out
+}
+
+func (r *mdRenderer) taskBox(checked bool) string {
+	if !r.opts.Color {
+		if checked {
+			return "[x]"
 		}
+		return "[ ]"
+	}
+	if checked {
+		return r.paint(mdTaskDone, "[✓]")
+	}
+	return r.paint(mdBullet, "[ ]")
+}
 
-		switch {
-		case strings.HasPrefix(trimmed, "### "):
-			out.WriteString(fmt.Sprintf("\x1b[1;38;5;111m%s\x1b[0m\n", strings.TrimPrefix(trimmed, "### ")))
-		case strings.HasPrefix(trimmed, "## "):
-			out.WriteString(fmt.Sprintf("\x1b[1;38;5;117m%s\x1b[0m\n", strings.TrimPrefix(trimmed, "## ")))
-		case strings.HasPrefix(trimmed, "# "):
-			out.WriteString(fmt.Sprintf("\x1b[1;38;5;159m%s\x1b[0m\n", strings.TrimPrefix(trimmed, "# ")))
-		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
-			out.WriteString("\x1b[38;5;212m•\x1b[0m " + strings.TrimSpace(trimmed[2:]) + "\n")
-		case strings.HasPrefix(trimmed, "> "):
-			out.WriteString("\x1b[38;5;244m│ \x1b[0m" + strings.TrimSpace(trimmed[2:]) + "\n")
-		default:
-			out.WriteString(styleInlineMarkdown(line) + "\n")
+// table draws a GFM table as a grid. When the grid is wider than
+// Options.Width, the widest columns shrink and their cells wrap.
+func (r *mdRenderer) table(n *markdown.Node) []string {
+	var rows [][]string
+	var aligns []markdown.Alignment
+	for row := n.FirstChild; row != nil; row = row.Next {
+		var cells []string
+		for cell := row.FirstChild; cell != nil; cell = cell.Next {
+			base := ""
+			if row.Header && r.opts.Color {
+				base = mdBold
+			}
+			cells = append(cells, strings.ReplaceAll(r.inlines(cell, base), "\n", " "))
+			if row.Header {
+				aligns = append(aligns, cell.Align[0])
+			}
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	widths := make([]int, len(aligns))
+	for _, cells := range rows {
+		for i, cell := range cells {
+			widths[i] = max(widths[i], style.Width(cell))
+		}
+	}
+	fitColumns(widths, r.tableWidth())
+
+	grid := tableGrid{left: "├", mid: "┼", right: "┤", line: "─", bar: "│"}
+	top := tableGrid{left: "┌", mid: "┬", right: "┐", line: "─"}
+	bottom := tableGrid{left: "└", mid: "┴", right: "┘", line: "─"}
+	if !r.opts.Color {
+		grid = tableGrid{left: "+", mid: "+", right: "+", line: "-", bar: "|"}
+		top, bottom = grid, grid
+	}
+
+	out := []string{r.paint(mdTableGrid, top.rule(widths))}
+	for i, cells := range rows {
+		out = append(out, r.tableRow(cells, widths, aligns, grid.bar)...)
+		if i == 0 {
+			out = append(out, r.paint(mdTableGrid, grid.rule(widths)))
+		}
+	}
+	return append(out, r.paint(mdTableGrid, bottom.rule(widths)))
+}
+
+func (r *mdRenderer) tableRow(cells []string, widths []int, aligns []markdown.Alignment, bar string) []string {
+	wrapped := make([][]string, len(widths))
+	height := 1
+	for i := range widths {
+		if i < len(cells) {
+			wrapped[i] = wrapText(cells[i], widths[i])
+		}
+		height = max(height, len(wrapped[i]))
+	}
+
+	sep := r.paint(mdTableGrid, bar)
+	var out []string
+	for line := 0; line < height; line++ {
+		var b strings.Builder
+		b.WriteString(sep)
+		for i, w := range widths {
+			text := ""
+			if line < len(wrapped[i]) {
+				text = wrapped[i][line]
+			}
+			b.WriteString(" " + alignCell(text, w, aligns[i]) + " " + sep)
+		}
+		out = append(out, b.String())
+	}
+	return out
+}
+
+type tableGrid struct {
+	left, mid, right, line, bar string
+}
+
+func (g tableGrid) rule(widths []int) string {
+	var b strings.Builder
+	b.WriteString(g.left)
+	for i, w := range widths {
+		if i > 0 {
+			b.WriteString(g.mid)
+		}
+		b.WriteString(strings.Repeat(g.line, w+2))
+	}
+	b.WriteString(g.right)
+	return b.String()
+}
+
+func (r *mdRenderer) tableWidth() int {
+	if r.width > 0 {
+		return r.width
+	}
+	return r.opts.Width
+}
+
+// fitColumns shrinks the widest columns until the grid fits in limit
+// columns or every column is down to minColumnWidth.
+func fitColumns(widths []int, limit int) {
+	const minColumnWidth = 3
+	if limit <= 0 {
+		return
+	}
+	total := 1
+	for _, w := range widths {
+		total += w + 3
+	}
+	for total > limit {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			return
 		}
+		widths[widest]--
+		total--
 	}
+}
 
-	return strings.TrimRight(out.String(), "\n") + "\n", nil
+func alignCell(s string, width int, align markdown.Alignment) string {
+	gap := width - style.Width(s)
+	if gap <= 0 {
+		return s
+	}
+	switch align {
+	case markdown.AlignRight:
+		return strings.Repeat(" ", gap) + s
+	case markdown.AlignCenter:
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+	return s + strings.Repeat(" ", gap)
 }
 
-func styleInlineMarkdown(line string) string {
-	line = stylePair(line, "**", "\x1b[1m", "\x1b[0m")
-	line = stylePair(line, "`", "\x1b[38;5;179m", "\x1b[0m")
-	return line
+// inlines renders the inline children of n. When base is set, the whole
+// run is painted with it and nested styles are layered on top.
+func (r *mdRenderer) inlines(n *markdown.Node, base string) string {
+	w := &styleWriter{color: r.opts.Color}
+	if base != "" {
+		w.push(base)
+	}
+	r.inlineChildren(w, n)
+	if base != "" {
+		w.pop()
+	}
+	return w.b.String()
 }
 
-func stylePair(line, delim, start, end string) string {
-	parts := strings.Split(line, delim)
-	if len(parts) < 3 {
-		return line
+func (r *mdRenderer) inlineChildren(w *styleWriter, n *markdown.Node) {
+	for c := n.FirstChild; c != nil; c = c.Next {
+		r.inline(w, c)
 	}
-	var out strings.Builder
-	for i, p := range parts {
-		if i%2 == 1 {
-			out.WriteString(start + p + end)
+}
+
+func (r *mdRenderer) inline(w *styleWriter, n *markdown.Node) {
+	switch n.Type {
+	case markdown.Text:
+		w.text(n.Literal)
+	case markdown.SoftBreak:
+		if r.width > 0 {
+			w.text(" ")
 		} else {
-			out.WriteString(p)
+			w.text("\n")
+		}
+	case markdown.HardBreak:
+		w.text("\n")
+	case markdown.Code:
+		w.styled(mdCode, n.Literal)
+	case markdown.HTMLInline:
+		w.styled(mdHTML, n.Literal)
+	case markdown.Emph:
+		w.push(mdItalic)
+		r.inlineChildren(w, n)
+		w.pop()
+	case markdown.Strong:
+		w.push(mdBold)
+		r.inlineChildren(w, n)
+		w.pop()
+	case markdown.Strikethrough:
+		if !r.opts.Color {
+			w.text("~~")
+			r.inlineChildren(w, n)
+			w.text("~~")
+			return
+		}
+		w.push(mdStrike)
+		r.inlineChildren(w, n)
+		w.pop()
+	case markdown.Link:
+		w.push(mdLink)
+		r.inlineChildren(w, n)
+		w.pop()
+		label := plainText(n)
+		if dest := n.Destination; dest != label && dest != "mailto:"+label && dest != "http://"+label {
+			w.styled(mdURL, " ("+dest+")")
+		}
+	case markdown.Image:
+		w.styled(mdURL, "[image: "+plainText(n)+"]")
+		if n.Destination != "" {
+			w.styled(mdURL, " ("+n.Destination+")")
+		}
+	}
+}
+
+func plainText(n *markdown.Node) string {
+	var b strings.Builder
+	n.Walk(func(c *markdown.Node) bool {
+		switch c.Type {
+		case markdown.Text, markdown.Code:
+			b.WriteString(c.Literal)
+		case markdown.SoftBreak, markdown.HardBreak:
+			b.WriteByte(' ')
+		}
+		return true
+	})
+	return b.String()
+}
+
+// styleWriter keeps a stack of active ANSI styles so that closing an inner
+// style restores the outer ones, and so every line it emits is
+// self-contained.
+type styleWriter struct {
+	b     strings.Builder
+	color bool
+	stack []string
+}
+
+func (w *styleWriter) push(style string) {
+	if !w.color {
+		return
+	}
+	w.stack = append(w.stack, style)
+	w.b.WriteString(style)
+}
+
+func (w *styleWriter) pop() {
+	if !w.color {
+		return
+	}
+	w.stack = w.stack[:len(w.stack)-1]
+	w.b.WriteString(ansiReset)
+	w.b.WriteString(strings.Join(w.stack, ""))
+}
+
+func (w *styleWriter) text(s string) {
+	if !w.color || len(w.stack) == 0 {
+		w.b.WriteString(s)
+		return
+	}
+	for i, part := range strings.Split(s, "\n") {
+		if i > 0 {
+			w.b.WriteString(ansiReset + "\n" + strings.Join(w.stack, ""))
 		}
+		w.b.WriteString(part)
 	}
-	return out.String()
+}
+
+func (w *styleWriter) styled(style, s string) {
+	w.push(style)
+	w.text(s)
+	w.pop()
 }
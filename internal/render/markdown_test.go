@@ -0,0 +1,186 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestRenderMarkdownNoColor(t *testing.T) {
+	in := "Title\n=====\n\n1. one\n2. two\n   - nested\n   - [x] done\n\n> quoted *text*\n\n###### deep\n"
+	out, err := renderMarkdown([]byte(in), Options{Color: false})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	if ansiRe.MatchString(out) {
+		t.Fatalf("expected no ANSI sequences, got %q", out)
+	}
+	want := "# Title\n\n1. one\n2. two\n   - nested\n   - [x] done\n\n> quoted text\n\n###### deep\n"
+	if out != want {
+		t.Fatalf("renderMarkdown mismatch:\n got %q\nwant %q", out, want)
+	}
+}
+
+func TestRenderMarkdownNestedStylesAreRestored(t *testing.T) {
+	out, err := renderMarkdown([]byte("**bold `code` tail**\n"), Options{Color: true})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	want := mdBold + "bold " + mdCode + "code" + ansiReset + mdBold + " tail" + ansiReset
+	if !strings.Contains(out, want) {
+		t.Fatalf("expected bold to resume after inline code, got %q", out)
+	}
+}
+
+func TestRenderMarkdownStylesDoNotSpanLines(t *testing.T) {
+	out, err := renderMarkdown([]byte("*emphasis that\ncontinues*\n"), Options{Color: true})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, mdItalic) || !strings.HasSuffix(line, ansiReset) {
+			t.Fatalf("line %q is not independently styled", line)
+		}
+	}
+}
+
+func TestRenderMarkdownCodeIsNotParsedAsMarkdown(t *testing.T) {
+	out, err := renderMarkdown([]byte("```\n# not a heading\n- not a list\n```\n"), Options{Color: false})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(out, "# not a heading\n- not a list\n") {
+		t.Fatalf("expected code block content verbatim, got %q", out)
+	}
+}
+
+func TestRenderMarkdownTableNoColor(t *testing.T) {
+	in := "| name | qty | note |\n|:-----|----:|:----:|\n| 日本 | 3 | ok |\n| apple | 12 | x |\n"
+	out, err := renderMarkdown([]byte(in), Options{Color: false})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	want := "+-------+-----+------+\n" +
+		"| name  | qty | note |\n" +
+		"+-------+-----+------+\n" +
+		"| 日本  |   3 |  ok  |\n" +
+		"| apple |  12 |  x   |\n" +
+		"+-------+-----+------+\n"
+	if out != want {
+		t.Fatalf("table mismatch:\n got %q\nwant %q", out, want)
+	}
+}
+
+func TestRenderMarkdownTableBoxDrawing(t *testing.T) {
+	out, err := renderMarkdown([]byte("| a | b |\n|---|---|\n| 1 | 2 |\n"), Options{Color: true})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	plain := ansiRe.ReplaceAllString(out, "")
+	want := "┌───┬───┐\n│ a │ b │\n├───┼───┤\n│ 1 │ 2 │\n└───┴───┘\n"
+	if plain != want {
+		t.Fatalf("table mismatch:\n got %q\nwant %q", plain, want)
+	}
+}
+
+func TestRenderMarkdownTableWrapsToWidth(t *testing.T) {
+	in := "| key | description |\n|---|---|\n| a | a fairly long cell that must wrap |\n"
+	out, err := renderMarkdown([]byte(in), Options{Color: false, Width: 24})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) < 6 {
+		t.Fatalf("expected the long cell to wrap, got %q", out)
+	}
+	for _, line := range lines {
+		if w := len([]rune(line)); w > 24 {
+			t.Fatalf("line %q is %d columns wide", line, w)
+		}
+	}
+}
+
+func TestRenderMarkdownReflowsToWidth(t *testing.T) {
+	in := "one two three four five\nsix seven eight nine ten\n\n" +
+		"> quoted words that wrap around the bar\n\n" +
+		"- item text that wraps under its marker\n\n" +
+		"```\nlong code line stays as it is in the source\n```\n"
+	out, err := renderMarkdown([]byte(in), Options{Color: false, Width: 24})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	want := "one two three four five\nsix seven eight nine ten\n\n" +
+		"> quoted words that wrap\n> around the bar\n\n" +
+		"- item text that wraps\n  under its marker\n\n" +
+		"```\nlong code line stays as it is in the source\n```\n"
+	if out != want {
+		t.Fatalf("reflow mismatch:\n got %q\nwant %q", out, want)
+	}
+}
+
+func TestRenderMarkdownWrapNeverKeepsLineBreaks(t *testing.T) {
+	out, err := renderMarkdown([]byte("one two three\nfour\n"), Options{Width: 8, Wrap: WrapNever})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	if out != "one two three\nfour\n" {
+		t.Fatalf("expected source line breaks, got %q", out)
+	}
+}
+
+func TestRenderMarkdownHighlightsFencedCode(t *testing.T) {
+	out, err := renderMarkdown([]byte("```go\nfunc main() {}\n```\n"), Options{Color: true})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if got := ansiRe.ReplaceAllString(lines[0], ""); got != "┌ Go" {
+		t.Fatalf("header = %q, want language name", got)
+	}
+	if !strings.Contains(lines[1], defaultTheme[TokenKeyword]+"func"+ansiReset) {
+		t.Fatalf("expected highlighted keyword, got %q", lines[1])
+	}
+}
+
+func TestRenderMarkdownUnknownFenceKeepsPlainCodeColor(t *testing.T) {
+	out, err := renderMarkdown([]byte("~~~ {.nosuchlang}\nfunc x\n~~~\n"), Options{Color: true})
+	if err != nil {
+		t.Fatalf("renderMarkdown returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if got := ansiRe.ReplaceAllString(lines[0], ""); got != "┌ {.nosuchlang}" {
+		t.Fatalf("header = %q", got)
+	}
+	if lines[1] != mdCode+"func x"+ansiReset {
+		t.Fatalf("body = %q", lines[1])
+	}
+}
+
+func TestDocReflowKeepsPrependedAndAppendedText(t *testing.T) {
+	src := input.Source{Name: "a.md", Data: []byte("one two three four five six\n")}
+	doc, err := Render(src, Options{Width: 12})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	doc.Prepend("==> a.md <==\n")
+	doc.Append("--\n")
+
+	wide, err := doc.Reflow(40)
+	if err != nil {
+		t.Fatalf("Reflow returned error: %v", err)
+	}
+	if want := "==> a.md <==\none two three four five six\n--\n"; wide.Body != want {
+		t.Fatalf("Reflow = %q, want %q", wide.Body, want)
+	}
+	if again, _ := wide.Reflow(12); again.Body != doc.Body {
+		t.Fatalf("Reflow back = %q, want %q", again.Body, doc.Body)
+	}
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+// numberLines adds a line-number gutter to a rendered body. When code
+// wrapping is on, lines wrap to the room left by the gutter and their
+// continuations get a blank label.
+func numberLines(body string, opts Options) (string, []int) {
+	last := 0
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		if opts.Number != NumberNonBlank || style.Strip(line) != "" {
+			last++
+		}
+	}
+	body, numbers, _ := numberLinesFrom(body, opts, 0, len(strconv.Itoa(last)))
+	return body, numbers
+}
+
+// numberLinesFrom numbers body as if before numbered lines came first,
+// with labels padded to digits. It also returns the last number used.
+func numberLinesFrom(body string, opts Options, before, digits int) (string, []int, int) {
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	blank := style.Gutter(strings.Repeat(" ", digits), opts.Color)
+	width := 0
+	if opts.wrapCode() {
+		width = max(opts.Width-style.Width(blank), minWrapWidth)
+	}
+
+	var (
+		b       strings.Builder
+		numbers []int
+		n       = before
+	)
+	for _, line := range lines {
+		gutter := blank
+		num := 0
+		if opts.Number != NumberNonBlank || style.Strip(line) != "" {
+			n++
+			num = n
+			gutter = style.Gutter(padLeft(strconv.Itoa(n), digits), opts.Color)
+		}
+		for i, part := range wrapText(line, width) {
+			if i > 0 {
+				gutter, num = blank, 0
+			}
+			if part == "" && !opts.Color {
+				gutter = strings.TrimRight(gutter, " ")
+			}
+			b.WriteString(gutter + part + "\n")
+			numbers = append(numbers, num)
+		}
+	}
+	return b.String(), numbers, n
+}
+
+func padLeft(s string, width int) string {
+	return strings.Repeat(" ", max(width-len(s), 0)) + s
+}
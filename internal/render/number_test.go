@@ -0,0 +1,66 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
+
+func TestNumberLinesAll(t *testing.T) {
+	body := strings.Repeat("x\n", 9) + "\nlast\n"
+	out, numbers := numberLines(body, Options{Number: NumberAll})
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if lines[0] != " 1  x" || lines[9] != "10" || lines[10] != "11  last" {
+		t.Fatalf("unexpected gutter: %q", lines)
+	}
+	if len(numbers) != 11 || numbers[10] != 11 {
+		t.Fatalf("numbers = %v", numbers)
+	}
+}
+
+func TestNumberLinesNonBlank(t *testing.T) {
+	out, numbers := numberLines("a\n\nb\n", Options{Number: NumberNonBlank})
+	if out != "1  a\n\n2  b\n" {
+		t.Fatalf("numberLines = %q", out)
+	}
+	if want := []int{1, 0, 2}; !reflect.DeepEqual(numbers, want) {
+		t.Fatalf("numbers = %v, want %v", numbers, want)
+	}
+}
+
+func TestNumberLinesColorUsesDimGutter(t *testing.T) {
+	out, _ := numberLines("a\n", Options{Color: true, Number: NumberAll})
+	if out != "\x1b[38;5;240m1 │\x1b[0m a\n" {
+		t.Fatalf("numberLines = %q", out)
+	}
+}
+
+func TestNumberLinesWrapsAfterGutter(t *testing.T) {
+	out, numbers := numberLines("one two three four five six\n", Options{Number: NumberAll, Width: 23, Wrap: WrapAlways})
+	if out != "1  one two three four\n   five six\n" {
+		t.Fatalf("numberLines = %q", out)
+	}
+	if want := []int{1, 0}; !reflect.DeepEqual(numbers, want) {
+		t.Fatalf("numbers = %v, want %v", numbers, want)
+	}
+}
+
+func TestRenderDoesNotNumberMarkdown(t *testing.T) {
+	doc, err := Render(input.Source{Name: "a.md", Data: []byte("hello\n")}, Options{Number: NumberAll})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if doc.Body != "hello\n" || doc.Numbers != nil {
+		t.Fatalf("markdown should not be numbered: %q %v", doc.Body, doc.Numbers)
+	}
+}
+
+func TestDocPrependShiftsNumbers(t *testing.T) {
+	doc := Doc{Body: "1  a\n", Numbers: []int{1}}
+	doc.Prepend("==> a <==\n")
+	if want := []int{0, 1}; !reflect.DeepEqual(doc.Numbers, want) {
+		t.Fatalf("numbers = %v, want %v", doc.Numbers, want)
+	}
+}
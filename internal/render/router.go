@@ -1,30 +1,91 @@
 package render
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/rodrwan/prettycat/internal/input"
 )
 
 func Render(src input.Source, opts Options) (Doc, error) {
-	kind := DetectKind(src.Name)
+	if src.IsDir {
+		return Doc{Title: src.Name, Body: renderTree(src.Name, src.Entries, opts), Kind: KindTree}, nil
+	}
+	det, forced := LookupLanguage(opts.Language)
+	if !forced {
+		det = Detect(src.InnerName(), src.Data)
+	}
+	if opts.Binary != BinaryRaw && input.IsBinary(src.Data) {
+		det = Detection{Kind: KindBinary}
+	}
+	if src.Rest != nil && src.Rest.Waits() && !forced && det.Kind == KindJSON && !bytes.Contains(bytes.TrimSpace(src.Data), []byte("\n")) {
+		// A single record from a pipe still being written is more likely
+		// the start of a log than a whole document.
+		det = detection("jsonl")
+	}
+	if src.Rest != nil && src.Rest.Following() && !streamable(det.Kind) {
+		// A followed source never ends, so it can only be shown line by
+		// line: a single JSON record looks like a JSON document.
+		if det.Kind == KindJSON {
+			det = detection("jsonl")
+		} else {
+			det = Detection{Kind: KindPlain}
+		}
+	}
+	kind := det.Kind
+
+	if src.Rest != nil {
+		if streamable(kind) {
+			body, numbers, next := renderBlock(kind, det.Language, src.Data, opts, 0)
+			stream := &Stream{src: src.Rest, kind: kind, language: det.Language, opts: opts, next: next}
+			return Doc{Title: src.Name, Body: body, Kind: kind, Language: det.Language, Numbers: numbers, Stream: stream}, nil
+		}
+		if err := src.ReadAll(); err != nil {
+			return Doc{}, err
+		}
+	}
 
 	var (
-		body string
-		err  error
+		body     string
+		warnings []string
+		err      error
 	)
 
 	switch kind {
+	case KindBinary:
+		body = renderHex(src.Data, opts)
 	case KindMarkdown:
 		body, err = renderMarkdown(src.Data, opts)
+	case KindJSON:
+		body, warnings = renderJSON(src.Name, src.Data, opts)
+	case KindDiff:
+		body = renderDiff(src.Data, opts)
+	case KindCSV:
+		body, warnings = renderCSV(src.Name, src.Data, opts)
+	case KindYAML:
+		body, warnings = renderYAML(src.Name, src.Data, opts)
+	case KindLog:
+		body = renderJSONLines(src.Data, opts)
 	case KindCode:
-		body, err = renderCode(src.Name, src.Data, opts)
+		body = highlight(det.Language, src.Data, opts)
 	default:
 		body = renderPlain(src.Data)
 	}
 	if err != nil {
 		return Doc{}, fmt.Errorf("render %s: %w", src.Name, err)
 	}
+	var numbers []int
+	switch {
+	case kind == KindMarkdown || kind == KindCSV || kind == KindBinary:
+	case opts.Number != NumberNone:
+		body, numbers = numberLines(body, opts)
+	case opts.wrapCode():
+		body = wrapBody(body, opts.Width)
+	}
 
-	return Doc{Title: src.Name, Body: body, Kind: kind}, nil
+	doc := Doc{Title: src.Name, Body: body, Kind: kind, Language: det.Language, Warnings: warnings, Numbers: numbers, source: &src, opts: opts}
+	if kind == KindCSV && warnings == nil {
+		doc.Sticky = csvHeaderLines
+	}
+	return doc, nil
 }
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
+
+// streamDigits is the gutter width of streamed documents, whose line count
+// is not known up front.
+const streamDigits = 6
+
+// Stream renders the rest of a source too large to load at once, one
+// block of whole lines at a time. Only line-oriented kinds are streamed, so
+// every block renders on its own; code constructs that span two blocks,
+// such as long block comments, are highlighted as if they were cut there.
+type Stream struct {
+	src      *input.Stream
+	kind     Kind
+	language string
+	opts     Options
+	next     int
+}
+
+// Block is one rendered block of a Stream.
+type Block struct {
+	// Offset and Size locate the source bytes of the block.
+	Offset int64
+	Size   int
+	// Before is the number of numbered lines that precede the block.
+	Before  int
+	Body    string
+	Numbers []int
+}
+
+func streamable(kind Kind) bool {
+	return kind == KindPlain || kind == KindCode || kind == KindLog
+}
+
+// Next renders the next block. It returns io.EOF after the last one.
+func (s *Stream) Next() (Block, error) {
+	data, offset, err := s.src.Next()
+	if len(data) == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return Block{}, err
+	}
+	b := Block{Offset: offset, Size: len(data), Before: s.next}
+	b.Body, b.Numbers, s.next = renderBlock(s.kind, s.language, data, s.opts, s.next)
+	if err == io.EOF {
+		err = nil
+	}
+	return b, err
+}
+
+// Seekable reports whether Rerender can render blocks again.
+func (s *Stream) Seekable() bool {
+	return s.src.Seekable()
+}
+
+// Following reports whether Next waits for data appended to the source
+// instead of ending with it.
+func (s *Stream) Following() bool {
+	return s.src.Following()
+}
+
+// Waits reports whether Next may wait for more data to be written, so
+// that callers should not block on it.
+func (s *Stream) Waits() bool {
+	return s.src.Waits()
+}
+
+// Rerender reads the source of b again and renders it, so callers can drop
+// rendered blocks they are not showing.
+func (s *Stream) Rerender(b Block) (string, []int, error) {
+	data := make([]byte, b.Size)
+	if _, err := s.src.ReadAt(data, b.Offset); err != nil {
+		return "", nil, err
+	}
+	body, numbers, _ := renderBlock(s.kind, s.language, data, s.opts, b.Before)
+	return body, numbers, nil
+}
+
+func (s *Stream) Close() error {
+	return s.src.Close()
+}
+
+// renderBlock renders a block of a streamed source whose preceding blocks
+// held before numbered lines, and returns the count after it.
+func renderBlock(kind Kind, language string, data []byte, opts Options, before int) (string, []int, int) {
+	var body string
+	switch kind {
+	case KindCode:
+		body = highlight(language, data, opts)
+	case KindLog:
+		body = renderJSONLines(data, opts)
+	default:
+		body = renderPlain(data)
+	}
+	// The renderers drop blank lines at the end, as the end of a whole
+	// source may, but those of a block lead into the next one.
+	if blank := len(data) - len(bytes.TrimRight(data, "\n")) - 1; blank > 0 {
+		body += strings.Repeat("\n", blank)
+	}
+	switch {
+	case opts.Number != NumberNone:
+		return numberLinesFrom(body, opts, before, streamDigits)
+	case opts.wrapCode():
+		body = wrapBody(body, opts.Width)
+	}
+	return body, nil, before
+}
@@ -0,0 +1,163 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
+
+// loadLarge writes content to a file named name and loads it, so sources
+// past the first block are streamed.
+func loadLarge(t *testing.T, name, content string) input.Source {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, _, err := input.Load([]string{path}, input.LoadOptions{}, os.Stdin, func(*os.File) bool { return true }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Sources[0]
+}
+
+func TestRenderStreamsCodeWithContinuousNumbers(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 30000; i++ {
+		fmt.Fprintf(&b, "x := %d\n", i)
+	}
+	src := loadLarge(t, "big.go", b.String())
+	doc, err := Render(src, Options{Number: NumberAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Stream == nil {
+		t.Fatal("expected the document to be streamed")
+	}
+	defer doc.Stream.Close()
+
+	lines := strings.Split(strings.TrimSuffix(doc.Body, "\n"), "\n")
+	want := 1
+	for {
+		for i, line := range lines {
+			if prefix := fmt.Sprintf("%6d  x := %d", want, want); line != prefix {
+				t.Fatalf("line %d = %q, want %q", i, line, prefix)
+			}
+			want++
+		}
+		blk, err := doc.Stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blk.Before != want-1 {
+			t.Fatalf("block starts after %d lines, want %d", blk.Before, want-1)
+		}
+		lines = strings.Split(strings.TrimSuffix(blk.Body, "\n"), "\n")
+	}
+	if want != 30001 {
+		t.Fatalf("streamed %d lines", want-1)
+	}
+}
+
+func TestRenderReadsWholeDocumentsThatCannotStream(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("[\n")
+	for i := 0; i < 60000; i++ {
+		fmt.Fprintf(&b, "  %d,\n", i)
+	}
+	b.WriteString("  0\n]\n")
+	src := loadLarge(t, "big.json", b.String())
+	if src.Rest == nil {
+		t.Fatal("expected the source to be streamed")
+	}
+	doc, err := Render(src, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Stream != nil || doc.Kind != KindJSON || len(doc.Warnings) != 0 {
+		t.Fatalf("doc = kind %v, stream %v, warnings %q", doc.Kind, doc.Stream, doc.Warnings)
+	}
+}
+
+func TestRenderFollowsASingleJSONRecordAsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(`{"level":"info","msg":"started"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, _, err := input.Load([]string{path}, input.LoadOptions{Follow: true}, os.Stdin, func(*os.File) bool { return true }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Render(res.Sources[0], Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Stream == nil || !doc.Stream.Following() {
+		t.Fatalf("expected a followed stream, got kind %v", doc.Kind)
+	}
+	defer doc.Stream.Close()
+	if doc.Kind != KindLog || !strings.Contains(doc.Body, "started") {
+		t.Fatalf("kind = %v, body = %q", doc.Kind, doc.Body)
+	}
+}
+
+func TestRenderStreamKeepsBlankLinesAtBlockEnds(t *testing.T) {
+	const blank = 300000
+	src := loadLarge(t, "notes", "start\n"+strings.Repeat("\n", blank)+"end\n")
+	doc, err := Render(src, Options{Number: NumberAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Stream == nil {
+		t.Fatal("expected the document to be streamed")
+	}
+	defer doc.Stream.Close()
+
+	body := doc.Body
+	for {
+		blk, err := doc.Stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body += blk.Body
+	}
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	if len(lines) != blank+2 {
+		t.Fatalf("streamed %d lines, want %d", len(lines), blank+2)
+	}
+	if want := fmt.Sprintf("%6d  end", blank+2); lines[len(lines)-1] != want {
+		t.Fatalf("last line = %q, want %q", lines[len(lines)-1], want)
+	}
+}
+
+func TestRenderTakesOneRecordFromAnOpenPipeAsALog(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	io.WriteString(w, `{"level":"info","msg":"started"}`+"\n")
+	res, _, err := input.Load(nil, input.LoadOptions{}, r, func(*os.File) bool { return false }, os.Open, io.ReadAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Render(res.Sources[0], Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Stream == nil || doc.Kind != KindLog {
+		t.Fatalf("kind = %v, stream %v, want a streamed log", doc.Kind, doc.Stream)
+	}
+}
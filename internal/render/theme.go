@@ -0,0 +1,58 @@
+package render
+
+import "strings"
+
+const ansiReset = "\x1b[0m"
+
+// Theme maps token classes to the ANSI sequence used to paint them. Classes
+// missing from the theme are written unstyled.
+type Theme map[TokenClass]string
+
+var defaultTheme = Theme{
+	TokenKeyword:  "\x1b[38;5;81m",
+	TokenString:   "\x1b[38;5;216m",
+	TokenNumber:   "\x1b[38;5;141m",
+	TokenComment:  "\x1b[38;5;244m",
+	TokenOperator: "\x1b[38;5;204m",
+	TokenType:     "\x1b[38;5;79m",
+	TokenBuiltin:  "\x1b[38;5;117m",
+}
+
+// genericTheme paints text of unknown languages in light gray so it still
+// reads as code.
+var genericTheme = Theme{
+	TokenText:       "\x1b[38;5;250m",
+	TokenIdentifier: "\x1b[38;5;250m",
+	TokenOperator:   "\x1b[38;5;250m",
+	TokenNumber:     "\x1b[38;5;250m",
+	TokenString:     "\x1b[38;5;216m",
+}
+
+// Paint writes tokens with the styles from theme. Styles are closed before
+// every newline and reopened after it, so each output line is self-contained.
+func (th Theme) Paint(tokens []Token) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		th.paintToken(&b, tok)
+	}
+	return b.String()
+}
+
+func (th Theme) paintToken(b *strings.Builder, tok Token) {
+	style := th[tok.Class]
+	if style == "" {
+		b.WriteString(tok.Text)
+		return
+	}
+	for i, line := range strings.Split(tok.Text, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if line == "" {
+			continue
+		}
+		b.WriteString(style)
+		b.WriteString(line)
+		b.WriteString(ansiReset)
+	}
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	treeDir   = "\x1b[1;38;5;75m"
+	treeGuide = "\x1b[38;5;240m"
+	treeCount = "\x1b[38;5;244m"
+)
+
+type treeNode struct {
+	name     string
+	dir      bool
+	children []*treeNode
+}
+
+// renderTree draws a directory listing like `tree`: the root, its entries
+// with connecting guides, and a count of directories and files. entries
+// are relative paths in walk order, directories with a trailing slash.
+func renderTree(root string, entries []string, opts Options) string {
+	paint := func(style, s string) string {
+		if !opts.Color {
+			return s
+		}
+		return style + s + ansiReset
+	}
+
+	top := &treeNode{dir: true}
+	nodes := map[string]*treeNode{"": top}
+	dirs, files := 0, 0
+	for _, e := range entries {
+		dir := strings.HasSuffix(e, "/")
+		e = strings.TrimSuffix(e, "/")
+		parent, name := "", e
+		if i := strings.LastIndexByte(e, '/'); i >= 0 {
+			parent, name = e[:i], e[i+1:]
+		}
+		p, ok := nodes[parent]
+		if !ok {
+			continue
+		}
+		n := &treeNode{name: name, dir: dir}
+		p.children = append(p.children, n)
+		if dir {
+			nodes[e] = n
+			dirs++
+		} else {
+			files++
+		}
+	}
+
+	// Without color the guides are ASCII, like tree --charset=ascii.
+	guides := [3]string{"├── ", "│   ", "└── "}
+	if !opts.Color {
+		guides = [3]string{"|-- ", "|   ", "`-- "}
+	}
+
+	var b strings.Builder
+	b.WriteString(paint(treeDir, strings.TrimSuffix(root, "/")+"/") + "\n")
+	var draw func(n *treeNode, prefix string)
+	draw = func(n *treeNode, prefix string) {
+		for i, c := range n.children {
+			branch, next := guides[0], guides[1]
+			if i == len(n.children)-1 {
+				branch, next = guides[2], "    "
+			}
+			name := c.name
+			if c.dir {
+				name = paint(treeDir, name+"/")
+			}
+			b.WriteString(paint(treeGuide, prefix+branch) + name + "\n")
+			draw(c, prefix+next)
+		}
+	}
+	draw(top, "")
+	b.WriteString("\n" + paint(treeCount, fmt.Sprintf("%s, %s", plural(dirs, "directory", "directories"), plural(files, "file", "files"))) + "\n")
+	return b.String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,37 @@
+package render
+
+import "testing"
+
+var treeEntries = []string{"README.md", "cmd/", "cmd/main.go", "internal/", "internal/app/", "internal/app/app.go", "go.mod"}
+
+func TestRenderTree(t *testing.T) {
+	want := "proj/\n" +
+		"├── README.md\n" +
+		"├── cmd/\n" +
+		"│   └── main.go\n" +
+		"├── internal/\n" +
+		"│   └── app/\n" +
+		"│       └── app.go\n" +
+		"└── go.mod\n" +
+		"\n" +
+		"3 directories, 4 files\n"
+	if got := ansiRe.ReplaceAllString(renderTree("proj", treeEntries, Options{Color: true}), ""); got != want {
+		t.Fatalf("renderTree mismatch:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderTreeNoColorUsesASCII(t *testing.T) {
+	want := "proj/\n" +
+		"|-- README.md\n" +
+		"|-- cmd/\n" +
+		"|   `-- main.go\n" +
+		"|-- internal/\n" +
+		"|   `-- app/\n" +
+		"|       `-- app.go\n" +
+		"`-- go.mod\n" +
+		"\n" +
+		"3 directories, 4 files\n"
+	if got := renderTree("proj", treeEntries, Options{}); got != want {
+		t.Fatalf("renderTree mismatch:\n got %q\nwant %q", got, want)
+	}
+}
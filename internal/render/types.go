@@ -1,20 +1,143 @@
 package render
 
+import (
+	"strings"
+
+	"github.com/rodrwan/prettycat/internal/input"
+)
+
 type Kind string
 
 const (
 	KindMarkdown Kind = "markdown"
 	KindCode     Kind = "code"
 	KindPlain    Kind = "plain"
+	KindJSON     Kind = "json"
+	KindLog      Kind = "log"
+	KindYAML     Kind = "yaml"
+	KindCSV      Kind = "csv"
+	KindDiff     Kind = "diff"
+	KindBinary   Kind = "binary"
+	KindTree     Kind = "tree"
+)
+
+// WrapMode controls which content is wrapped to Options.Width. Prose is
+// reflowed unless wrapping is off; code and plain text only wrap when
+// explicitly asked to.
+type WrapMode string
+
+const (
+	WrapAuto   WrapMode = "auto"
+	WrapNever  WrapMode = "never"
+	WrapAlways WrapMode = "always"
+)
+
+// NumberMode selects which lines of code and plain text get a number in
+// the gutter.
+type NumberMode string
+
+const (
+	NumberNone     NumberMode = ""
+	NumberAll      NumberMode = "all"
+	NumberNonBlank NumberMode = "nonblank"
+)
+
+// BinaryMode selects how content that looks binary is shown.
+type BinaryMode string
+
+const (
+	BinaryHex  BinaryMode = "hex"
+	BinaryRaw  BinaryMode = "raw"
+	BinarySkip BinaryMode = "skip"
 )
 
 type Options struct {
-	Color bool
-	Width int
+	Color  bool
+	Width  int
+	Wrap   WrapMode
+	Number NumberMode
+	// SideBySide lays diffs out as two columns.
+	SideBySide bool
+	// Language forces a renderer and language instead of detecting them.
+	Language string
+	// Binary is how binary input is rendered; "" means BinaryHex. Skipping
+	// is up to the caller, Render dumps skipped input as hex.
+	Binary BinaryMode
+}
+
+// wrapProse reports whether prose should be reflowed to Width.
+func (o Options) wrapProse() bool {
+	return o.Width > 0 && o.Wrap != WrapNever
+}
+
+// wrapCode reports whether code and plain text should wrap at Width.
+func (o Options) wrapCode() bool {
+	return o.Width > 0 && o.Wrap == WrapAlways
 }
 
 type Doc struct {
 	Title string
 	Body  string
 	Kind  Kind
+	// Language is the detected language ID, "" when unknown.
+	Language string
+	// Warnings are non-fatal problems found while rendering, such as a
+	// parse error that made the renderer fall back to raw output.
+	Warnings []string
+	// Sticky is the number of lines starting at StickyAt that the pager keeps
+	// pinned at the top while the rest of the document scrolls.
+	Sticky   int
+	StickyAt int
+	// Numbers holds the gutter number of every Body line, 0 for lines
+	// without one. It is nil when line numbering is off.
+	Numbers []int
+	// Stream renders the rest of a document too large to load at once. Body
+	// then only holds its first block, and Footer goes after the last one.
+	Stream *Stream
+	Footer string
+
+	// source and opts are what Render made the document from, kept so that
+	// Reflow can render it again; source is nil for other documents.
+	source *input.Source
+	opts   Options
+	// prefix and suffix were added by Prepend and Append.
+	prefix, suffix string
+}
+
+// Append adds s at the end of the document.
+func (d *Doc) Append(s string) {
+	d.suffix += s
+	if d.Stream != nil {
+		d.Footer += s
+		return
+	}
+	d.Body += s
+}
+
+// Prepend adds s before the body, keeping Numbers aligned.
+func (d *Doc) Prepend(s string) {
+	d.prefix = s + d.prefix
+	d.Body = s + d.Body
+	d.StickyAt += strings.Count(s, "\n")
+	if d.Numbers != nil {
+		d.Numbers = append(make([]int, strings.Count(s, "\n")), d.Numbers...)
+	}
+}
+
+// Reflow renders the document again at width, keeping what was prepended
+// and appended to it. Streamed documents, whose blocks are rendered as they
+// are read, and documents not made by Render are returned unchanged.
+func (d Doc) Reflow(width int) (Doc, error) {
+	if d.source == nil || d.Stream != nil || d.opts.Width == width {
+		return d, nil
+	}
+	opts := d.opts
+	opts.Width = width
+	doc, err := Render(*d.source, opts)
+	if err != nil {
+		return d, err
+	}
+	doc.Prepend(d.prefix)
+	doc.Append(d.suffix)
+	return doc, nil
 }
@@ -0,0 +1,175 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+// tabWidth is the tab stop wrapped text is expanded to, the terminal's
+// default.
+const tabWidth = 8
+
+type wrapAtom struct {
+	text  string
+	width int
+	space bool
+}
+
+// wrapText breaks s into lines of at most width columns at spaces. ANSI
+// styles active at a break are closed at the end of the line and reopened on
+// the next one. Words wider than width are split. Tabs are expanded first,
+// since their width depends on where they fall.
+func wrapText(s string, width int) []string {
+	if width <= 0 {
+		return []string{s}
+	}
+	s = style.ExpandTabs(s, tabWidth)
+	if style.Width(s) <= width {
+		return []string{s}
+	}
+
+	var (
+		lines   []string
+		line    strings.Builder
+		lineW   int
+		pending []wrapAtom
+		active  string
+	)
+	flush := func() {
+		if active != "" {
+			line.WriteString(ansiReset)
+		}
+		lines = append(lines, line.String())
+		line.Reset()
+		line.WriteString(active)
+		lineW = 0
+		pending = nil
+	}
+	write := func(a wrapAtom) {
+		line.WriteString(a.text)
+		lineW += a.width
+		active = trackStyle(active, a.text)
+	}
+
+	for _, a := range splitAtoms(s) {
+		if a.space {
+			pending = append(pending, a)
+			continue
+		}
+		spaceW := 0
+		for _, p := range pending {
+			spaceW += p.width
+		}
+		switch {
+		case lineW == 0 || lineW+spaceW+a.width <= width:
+			if lineW > 0 || len(lines) == 0 {
+				for _, p := range pending {
+					write(p)
+				}
+			}
+			pending = nil
+		default:
+			flush()
+		}
+		// Split words wider than a line, and the first word when it does not
+		// fit after the indentation of the first line.
+		for a.width > width-lineW && width-lineW > 0 {
+			head, tail := splitAtWidth(a.text, width-lineW)
+			write(wrapAtom{text: head, width: style.Width(head)})
+			flush()
+			a = wrapAtom{text: tail, width: style.Width(tail)}
+		}
+		write(a)
+	}
+	if active != "" {
+		line.WriteString(ansiReset)
+	}
+	return append(lines, line.String())
+}
+
+// wrapBody wraps every line of an already rendered body.
+func wrapBody(body string, width int) string {
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, wrapText(line, width)...)
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// splitAtoms splits s into runs of spaces and runs of everything else.
+// Escape sequences stick to the word that follows them.
+func splitAtoms(s string) []wrapAtom {
+	var atoms []wrapAtom
+	start := 0
+	width := 0
+	inSpace := false
+	emit := func(end int) {
+		if end > start {
+			atoms = append(atoms, wrapAtom{text: s[start:end], width: width, space: inSpace})
+		}
+		start = end
+		width = 0
+	}
+	for i := 0; i < len(s); {
+		if n := style.EscapeLen(s[i:]); n > 0 {
+			if inSpace {
+				emit(i)
+				inSpace = false
+			}
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		isSpace := r == ' '
+		if isSpace != inSpace {
+			emit(i)
+			inSpace = isSpace
+		}
+		width += style.RuneWidth(r)
+		i += size
+	}
+	emit(len(s))
+	return atoms
+}
+
+// splitAtWidth cuts s after at most width visible columns.
+func splitAtWidth(s string, width int) (string, string) {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := style.EscapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := style.RuneWidth(r)
+		if w+rw > width {
+			return s[:i], s[i:]
+		}
+		w += rw
+		i += size
+	}
+	return s, ""
+}
+
+// trackStyle returns the SGR sequences still in effect after writing s on
+// top of active.
+func trackStyle(active, s string) string {
+	for i := 0; i < len(s); {
+		n := style.EscapeLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		if seq == ansiReset || seq == "\x1b[m" {
+			active = ""
+		} else if strings.HasSuffix(seq, "m") {
+			active += seq
+		}
+		i += n
+	}
+	return active
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/prettycat/internal/style"
+)
+
+func TestWrapTextBreaksAtSpaces(t *testing.T) {
+	got := wrapText("the quick brown fox jumps", 10)
+	want := []string{"the quick", "brown fox", "jumps"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrapText = %q, want %q", got, want)
+	}
+}
+
+func TestWrapTextSplitsLongWords(t *testing.T) {
+	got := wrapText("abcdefghij xy", 4)
+	want := []string{"abcd", "efgh", "ij", "xy"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrapText = %q, want %q", got, want)
+	}
+}
+
+func TestWrapTextCarriesStyles(t *testing.T) {
+	in := mdBold + "bold words here" + ansiReset + " plain"
+	got := wrapText(in, 10)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 lines, got %q", got)
+	}
+	if !strings.HasSuffix(got[0], ansiReset) {
+		t.Fatalf("first line should close its style: %q", got[0])
+	}
+	if !strings.HasPrefix(got[1], mdBold) {
+		t.Fatalf("second line should reopen bold: %q", got[1])
+	}
+	for _, line := range got {
+		if w := style.Width(line); w > 10 {
+			t.Fatalf("line %q is %d columns wide", line, w)
+		}
+	}
+}
+
+func TestWrapTextUsesDisplayWidth(t *testing.T) {
+	got := wrapText("日本語 テキスト", 8)
+	want := []string{"日本語", "テキスト"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrapText = %q, want %q", got, want)
+	}
+}
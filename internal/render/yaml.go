@@ -0,0 +1,380 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	yamlKey      = "\x1b[38;5;117m"
+	yamlString   = "\x1b[38;5;216m"
+	yamlNumber   = "\x1b[38;5;141m"
+	yamlBool     = "\x1b[38;5;81m"
+	yamlNull     = "\x1b[38;5;204m"
+	yamlAnchor   = "\x1b[38;5;179m"
+	yamlTag      = "\x1b[38;5;79m"
+	yamlComment  = "\x1b[38;5;244m"
+	yamlPunct    = "\x1b[38;5;250m"
+	yamlDocument = "\x1b[1;38;5;212m"
+	yamlDash     = "\x1b[38;5;212m"
+	yamlGuide    = "\x1b[38;5;238m"
+	yamlError    = "\x1b[1;38;5;203m"
+)
+
+var (
+	reYAMLNumber = regexp.MustCompile(`^[-+]?(?:\d[\d_]*(?:\.\d*)?(?:[eE][-+]?\d+)?|\.\d+(?:[eE][-+]?\d+)?|0x[0-9a-fA-F]+|0o[0-7]+|\.inf|\.Inf|\.INF|\.nan|\.NaN|\.NAN)$`)
+	reYAMLBlock  = regexp.MustCompile(`^[|>][-+0-9]*$`)
+)
+
+// yamlNode is an open collection entry: a mapping key or a sequence item.
+type yamlNode struct {
+	indent int
+	seq    bool
+	// open is set when the entry has no inline value, so more-indented lines
+	// belong to it.
+	open bool
+	// child is the indentation of the first child, -1 until one is seen.
+	child int
+}
+
+type yamlRenderer struct {
+	name     string
+	color    bool
+	stack    []yamlNode
+	root     int
+	block    int // indentation of the owner of a block scalar, -1 outside one
+	flow     int // unclosed [ and { from previous lines
+	lineNo   int
+	warnings []string
+}
+
+// renderYAML highlights YAML line by line, following the indentation
+// structure closely enough to draw guides and point at tab indentation and
+// misaligned entries. It does not build a document tree.
+func renderYAML(name string, in []byte, opts Options) (string, []string) {
+	r := &yamlRenderer{name: name, color: opts.Color, root: -1, block: -1}
+	lines := strings.Split(strings.TrimRight(string(in), "\n"), "\n")
+	var out []string
+	for _, line := range lines {
+		r.lineNo++
+		text, problem, col := r.line(strings.TrimRight(line, "\r"))
+		out = append(out, text)
+		if problem != "" {
+			r.warnings = append(r.warnings, fmt.Sprintf("%s:%d:%d: %s", name, r.lineNo, col+1, problem))
+			out = append(out, r.paint(yamlError, strings.Repeat(" ", col)+"^ "+problem))
+		}
+	}
+	return strings.Join(out, "\n") + "\n", r.warnings
+}
+
+func (r *yamlRenderer) paint(style, s string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return style + s + ansiReset
+}
+
+// line renders one line and returns a problem description with its column
+// when the line breaks the indentation rules.
+func (r *yamlRenderer) line(line string) (string, string, int) {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	body := line[indent:]
+	trimmed := strings.TrimSpace(body)
+
+	if r.block >= 0 {
+		if trimmed == "" || indent > r.block {
+			return r.guides(indent) + r.paint(yamlString, body), "", 0
+		}
+		r.block = -1
+	}
+
+	if strings.HasPrefix(body, "\t") {
+		return line, "tab character used for indentation", indent
+	}
+	if trimmed == "" {
+		return line, "", 0
+	}
+	if strings.HasPrefix(trimmed, "#") {
+		return r.guides(indent) + r.paint(yamlComment, body), "", 0
+	}
+	if indent == 0 && strings.HasPrefix(line, "%") {
+		return r.paint(yamlComment, line), "", 0
+	}
+	if indent == 0 && (line == "---" || line == "..." || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "... ")) {
+		r.stack, r.root, r.flow = nil, -1, 0
+		rest := r.value(line[3:], 3)
+		return r.paint(yamlDocument, line[:3]) + rest, "", 0
+	}
+	if r.flow > 0 {
+		r.flow += flowDelta(body)
+		return r.guides(indent) + r.scalar(body), "", 0
+	}
+
+	problem := r.place(indent, body)
+	prefix := r.guides(indent)
+	if problem != "" {
+		prefix = strings.Repeat(" ", indent)
+	}
+	return prefix + r.entry(body, indent), problem, indent
+}
+
+// place fits a line that starts at indent into the open structure.
+func (r *yamlRenderer) place(indent int, body string) string {
+	seq := body == "-" || strings.HasPrefix(body, "- ")
+	for len(r.stack) > 0 {
+		top := r.stack[len(r.stack)-1]
+		if top.indent > indent {
+			r.stack = r.stack[:len(r.stack)-1]
+			continue
+		}
+		// A key's sequence may sit at the key's own indentation.
+		if top.indent == indent && !(seq && !top.seq && top.open) {
+			r.stack = r.stack[:len(r.stack)-1]
+			continue
+		}
+		break
+	}
+
+	if len(r.stack) == 0 {
+		if r.root < 0 {
+			r.root = indent
+		}
+		if indent != r.root {
+			return "bad indentation: does not line up with any parent entry"
+		}
+		return ""
+	}
+
+	parent := &r.stack[len(r.stack)-1]
+	if !parent.open {
+		if _, _, isKey := splitYAMLKey(stripSequence(body)); isKey || seq {
+			return "bad indentation: entry nested under a scalar value"
+		}
+		return ""
+	}
+	if parent.child < 0 {
+		parent.child = indent
+	} else if parent.child != indent {
+		return "bad indentation: siblings are not aligned"
+	}
+	return ""
+}
+
+// entry highlights the content of a structural line and pushes the
+// collection entries it opens.
+func (r *yamlRenderer) entry(body string, indent int) string {
+	var b strings.Builder
+	col := indent
+	for body == "-" || strings.HasPrefix(body, "- ") {
+		rest := strings.TrimLeft(body[1:], " ")
+		width := len(body) - len(rest)
+		r.stack = append(r.stack, yamlNode{indent: col, seq: true, open: rest == "" || strings.HasPrefix(rest, "#"), child: -1})
+		if rest != "" {
+			r.stack[len(r.stack)-1].open = true
+			r.stack[len(r.stack)-1].child = col + width
+		}
+		b.WriteString(r.paint(yamlDash, "-") + body[1:width])
+		col += width
+		body = rest
+	}
+
+	key, value, ok := splitYAMLKey(body)
+	if !ok {
+		if body != "" && len(r.stack) > 0 && r.stack[len(r.stack)-1].seq {
+			r.stack[len(r.stack)-1].open = false
+		}
+		b.WriteString(r.value(body, col))
+		return b.String()
+	}
+
+	v := strings.TrimSpace(stripYAMLComment(value))
+	r.stack = append(r.stack, yamlNode{indent: col, open: v == "" || isYAMLProperty(v), child: -1})
+	b.WriteString(r.paint(yamlKey, key) + r.paint(yamlPunct, ":"))
+	b.WriteString(r.value(value, col))
+	return b.String()
+}
+
+// value highlights what follows a key or a dash: properties, a scalar or
+// flow collection, and a trailing comment. owner is the column of the entry
+// the value belongs to, used to track block scalars.
+func (r *yamlRenderer) value(s string, owner int) string {
+	var b strings.Builder
+	lead := len(s) - len(strings.TrimLeft(s, " "))
+	b.WriteString(s[:lead])
+	s = s[lead:]
+
+	s, comment := splitYAMLComment(s)
+	for s != "" && (s[0] == '&' || s[0] == '*' || s[0] == '!') {
+		end := strings.IndexByte(s, ' ')
+		if end < 0 {
+			end = len(s)
+		}
+		style := yamlAnchor
+		if s[0] == '!' {
+			style = yamlTag
+		}
+		b.WriteString(r.paint(style, s[:end]))
+		rest := strings.TrimLeft(s[end:], " ")
+		b.WriteString(s[end : len(s)-len(rest)])
+		s = rest
+	}
+
+	trimmed := strings.TrimRight(s, " ")
+	switch {
+	case reYAMLBlock.MatchString(trimmed):
+		r.block = owner
+		b.WriteString(r.paint(yamlPunct, s))
+	case strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{"):
+		r.flow = max(flowDelta(s), 0)
+		b.WriteString(r.scalar(s))
+	default:
+		b.WriteString(r.scalar(s))
+	}
+	b.WriteString(r.paint(yamlComment, comment))
+	return b.String()
+}
+
+// scalar colors a plain or quoted scalar by its resolved type, and flow
+// collections token by token.
+func (r *yamlRenderer) scalar(s string) string {
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return s
+	}
+	switch t {
+	case "true", "false", "True", "False", "TRUE", "FALSE", "yes", "no", "on", "off":
+		return r.paint(yamlBool, s)
+	case "null", "Null", "NULL", "~":
+		return r.paint(yamlNull, s)
+	}
+	if reYAMLNumber.MatchString(t) {
+		return r.paint(yamlNumber, s)
+	}
+	if strings.ContainsAny(t[:1], "[{]},") {
+		if !r.color {
+			return s
+		}
+		lang, _ := LanguageByID("yaml")
+		return defaultTheme.Paint(Lex(lang, s))
+	}
+	return r.paint(yamlString, s)
+}
+
+// guides draws a vertical rule at the column of every open ancestor.
+func (r *yamlRenderer) guides(indent int) string {
+	if !r.color || indent == 0 {
+		return strings.Repeat(" ", indent)
+	}
+	cols := []byte(strings.Repeat(" ", indent))
+	var b strings.Builder
+	for _, n := range r.stack {
+		if n.indent < indent && !n.seq {
+			cols[n.indent] = '|'
+		}
+	}
+	for _, c := range cols {
+		if c == '|' {
+			b.WriteString(yamlGuide + "│" + ansiReset)
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// splitYAMLKey splits "key: value" outside of quotes and flow collections.
+// key keeps its quotes; value starts right after the colon.
+func splitYAMLKey(s string) (key, value string, ok bool) {
+	if s == "" || strings.ContainsAny(s[:1], "[{#|>&*!%@`") {
+		return "", "", false
+	}
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == '#' && i > 0 && s[i-1] == ' ':
+			return "", "", false
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			return s[:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func stripSequence(s string) string {
+	for s == "-" || strings.HasPrefix(s, "- ") {
+		s = strings.TrimLeft(s[1:], " ")
+	}
+	return s
+}
+
+// splitYAMLComment separates a trailing " # comment", ignoring # inside
+// quotes.
+func splitYAMLComment(s string) (string, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || s[i-1] == ' ' || strings.IndexByte("[{,:", s[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			end := i
+			for end > 0 && s[end-1] == ' ' {
+				end--
+			}
+			return s[:end], s[end:]
+		}
+	}
+	return s, ""
+}
+
+func stripYAMLComment(s string) string {
+	v, _ := splitYAMLComment(s)
+	return v
+}
+
+// isYAMLProperty reports whether v only holds an anchor or tag, which
+// leaves the node itself on the following lines.
+func isYAMLProperty(v string) bool {
+	for _, f := range strings.Fields(v) {
+		if f[0] != '&' && f[0] != '!' {
+			return reYAMLBlock.MatchString(f)
+		}
+	}
+	return true
+}
+
+func flowDelta(s string) int {
+	s = stripYAMLComment(s)
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderYAMLNoColorKeepsText(t *testing.T) {
+	in := "# c\na: 1\nlist:\n- x\n- y: 2\n  z: |\n    text: here\n---\nb: [1, 2]\n"
+	out, warnings := renderYAML("a.yaml", []byte(in), Options{})
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %q", warnings)
+	}
+	if out != in {
+		t.Fatalf("renderYAML mismatch:\n got %q\nwant %q", out, in)
+	}
+}
+
+func TestRenderYAMLHighlightsStructure(t *testing.T) {
+	in := "base: &b\n  name: web # comment\n  port: 80\n  on: true\n  none: ~\nother: *b\ntagged: !!str x\n---\n"
+	out, _ := renderYAML("a.yaml", []byte(in), Options{Color: true})
+	for _, want := range []string{
+		yamlKey + "base" + ansiReset,
+		yamlAnchor + "&b" + ansiReset,
+		yamlGuide + "│" + ansiReset + " " + yamlKey + "name",
+		yamlString + "web" + ansiReset + yamlComment + " # comment" + ansiReset,
+		yamlNumber + "80" + ansiReset,
+		yamlBool + "true" + ansiReset,
+		yamlNull + "~" + ansiReset,
+		yamlAnchor + "*b" + ansiReset,
+		yamlTag + "!!str" + ansiReset,
+		yamlDocument + "---" + ansiReset,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%q", want, out)
+		}
+	}
+}
+
+func TestRenderYAMLReportsIndentationErrors(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"tab", "a:\n\tb: 1\n", "a.yaml:2:1: tab character used for indentation"},
+		{"under scalar", "a: 1\n  b: 2\n", "a.yaml:2:3: bad indentation: entry nested under a scalar value"},
+		{"misaligned siblings", "a:\n    b: 1\n  c: 2\n", "a.yaml:3:3: bad indentation: siblings are not aligned"},
+		{"root", "a: 1\n b: 2\n", "a.yaml:2:2: bad indentation: entry nested under a scalar value"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out, warnings := renderYAML("a.yaml", []byte(tc.in), Options{})
+			if len(warnings) != 1 || warnings[0] != tc.want {
+				t.Fatalf("warnings = %q, want %q", warnings, tc.want)
+			}
+			if !strings.Contains(out, "^ ") {
+				t.Fatalf("expected an inline marker, got %q", out)
+			}
+		})
+	}
+}
+
+func TestRenderYAMLAllowsScalarContinuation(t *testing.T) {
+	in := "desc: a long\n  plain scalar\nnext: 1\n"
+	if _, warnings := renderYAML("a.yaml", []byte(in), Options{}); len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %q", warnings)
+	}
+}
@@ -11,6 +11,7 @@ const (
 	gray   = "\x1b[38;5;244m"
 	pink   = "\x1b[38;5;212m"
 	border = "\x1b[38;5;240m"
+	dim    = "\x1b[38;5;240m"
 )
 
 func Header(title string, color bool) string {
@@ -26,3 +27,12 @@ func Separator(color bool) string {
 	}
 	return gray + border + strings.Repeat("─", 40) + reset + "\n"
 }
+
+// Gutter formats a line-number label for the left margin. label is already
+// padded to the gutter width.
+func Gutter(label string, color bool) string {
+	if !color {
+		return label + "  "
+	}
+	return dim + label + " │" + reset + " "
+}
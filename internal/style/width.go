@@ -0,0 +1,233 @@
+package style
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Strip removes ANSI escape sequences from s.
+func Strip(s string) string {
+	if strings.IndexByte(s, 0x1b) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n := EscapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// EscapeLen returns the length of the ANSI escape sequence at the start of
+// s, or 0 when s does not start with one.
+func EscapeLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+	return 2
+}
+
+// Width returns the number of terminal columns s occupies, ignoring ANSI
+// escape sequences.
+func Width(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := EscapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w += RuneWidth(r)
+		i += size
+	}
+	return w
+}
+
+// RuneWidth returns 0 for combining and control runes, 2 for East Asian
+// wide and fullwidth runes (including most emoji) and 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0, r < 32, r >= 0x7f && r < 0xa0:
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf), r == 0x200b:
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x16fe4},
+	{0x17000, 0x18cff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
+
+func isWide(r rune) bool {
+	lo, hi := 0, len(wideRanges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid][0]:
+			hi = mid
+		case r > wideRanges[mid][1]:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandTabs replaces tabs with spaces up to the next multiple of tabWidth
+// columns, so that widths measure what the terminal shows. Escape
+// sequences are kept and take no columns.
+func ExpandTabs(s string, tabWidth int) string {
+	if strings.IndexByte(s, '\t') < 0 {
+		return s
+	}
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(s); {
+		if n := EscapeLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == '\t' {
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		} else {
+			b.WriteString(s[i : i+size])
+			col += RuneWidth(r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// Slice returns the part of s shown in columns [from, from+width) and the
+// column after its last rune. Escape sequences up to the end of the slice
+// are kept so that it keeps the colors of the whole line, and a reset is
+// added after them. A wide rune cut by the left edge becomes spaces; one
+// cut by the right edge is left out.
+func Slice(s string, from, width int) (string, int) {
+	var b strings.Builder
+	col, end := 0, from
+	escapes := false
+	for i := 0; i < len(s); {
+		if n := EscapeLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			escapes = true
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := RuneWidth(r)
+		if col+rw > from+width {
+			break
+		}
+		switch {
+		case col+rw <= from:
+		case col < from:
+			b.WriteString(strings.Repeat(" ", col+rw-from))
+			end = col + rw
+		default:
+			b.WriteString(s[i : i+size])
+			end = col + rw
+		}
+		col += rw
+		i += size
+	}
+	if escapes {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String(), end
+}
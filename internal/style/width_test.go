@@ -0,0 +1,49 @@
+package style
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{in: "hello", want: 5},
+		{in: "\x1b[1;38;5;159mhello\x1b[0m", want: 5},
+		{in: "日本語", want: 6},
+		{in: "é", want: 1},
+		{in: "🚀 go", want: 5},
+		{in: "ñandú", want: 5},
+	}
+	for _, tc := range tests {
+		if got := Width(tc.in); got != tc.want {
+			t.Errorf("Width(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	if got := Strip("\x1b[38;5;81mfunc\x1b[0m main"); got != "func main" {
+		t.Fatalf("Strip = %q, want %q", got, "func main")
+	}
+}
+
+func TestSlice(t *testing.T) {
+	tests := []struct {
+		in          string
+		from, width int
+		want        string
+		end         int
+	}{
+		{in: "hello world", from: 6, width: 3, want: "wor", end: 9},
+		{in: "short", from: 2, width: 10, want: "ort", end: 5},
+		{in: "\x1b[38;5;81mfunc\x1b[0m main", from: 2, width: 4, want: "\x1b[38;5;81mnc\x1b[0m m\x1b[0m", end: 6},
+		{in: "日本語", from: 1, width: 3, want: " 本", end: 4},
+		{in: "日本語", from: 0, width: 3, want: "日", end: 2},
+	}
+	for _, tc := range tests {
+		got, end := Slice(tc.in, tc.from, tc.width)
+		if got != tc.want || end != tc.end {
+			t.Errorf("Slice(%q, %d, %d) = %q, %d, want %q, %d", tc.in, tc.from, tc.width, got, end, tc.want, tc.end)
+		}
+	}
+}